@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata" // timezone names must resolve even without system zoneinfo
 
 	"github.com/hari4698/hardinfinity/internal/api"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/blob"
 	"github.com/hari4698/hardinfinity/internal/db"
+	"github.com/hari4698/hardinfinity/internal/migrate"
+	"github.com/hari4698/hardinfinity/internal/rollover"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/migrations"
 	"github.com/joho/godotenv"
 )
 
@@ -17,12 +26,57 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 	
-	if err := db.Initialize(); err != nil {
+	pool, err := db.Connect(context.Background())
+	if err != nil {
 		log.Fatalf("Failed to initilize database: %v", err)
 	}
-	defer db.Close()
-	
-	server := api.NewServer()
+	defer pool.Close()
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+
+	// "api migrate ..." manages the schema and exits
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" {
+			log.Fatalf("Unknown command %q", os.Args[1])
+		}
+		if err := migrator.Run(context.Background(), os.Stdout, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if os.Getenv("MIGRATE_ON_START") == "true" {
+		if err := migrator.Up(context.Background(), 0); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+	}
+
+	verifier, err := auth.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	st := store.NewPostgres(pool)
+
+	blobs, err := blob.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure blob storage: %v", err)
+	}
+
+	// Closes days that ended without an entry; safe to run on every replica
+	interval := rollover.DefaultInterval
+	if v := os.Getenv("ROLLOVER_INTERVAL"); v != "" {
+		if interval, err = time.ParseDuration(v); err != nil {
+			log.Fatalf("Invalid ROLLOVER_INTERVAL: %v", err)
+		}
+	}
+	scheduler := rollover.New(st, interval)
+	scheduler.Start()
+
+	server := api.NewServer(verifier, st, blobs, scheduler)
 	go func() {
 		if err := server.Start(); err != nil {
 			log.Fatalf("Server failed to start: %v", err)
@@ -41,4 +95,4 @@ func main() {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
 	fmt.Println("Server stopped succesfully")
-}
\ No newline at end of file
+}
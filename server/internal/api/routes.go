@@ -7,10 +7,12 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/blob"
 	"github.com/hari4698/hardinfinity/internal/handlers"
+	"github.com/hari4698/hardinfinity/internal/store"
 )
 
-func Routes() http.Handler {
+func Routes(verifier auth.Verifier, users store.UserStore, blobs blob.Store, h *handlers.Handler) http.Handler {
 	r := chi.NewRouter()
 
 	//Middleware
@@ -21,7 +23,7 @@ func Routes() http.Handler {
 	// CORS middleware
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Should be restricted in production
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -34,66 +36,108 @@ func Routes() http.Handler {
 		w.Write([]byte("OK"))
 	})
 
+	// Webhooks are authenticated by signature rather than by session
+	r.Post("/webhooks/clerk", h.ClerkWebhook)
+
+	// Blob stores without their own server, like the local one, serve signed
+	// URLs here; the signature stands in for the session
+	if server, ok := blobs.(http.Handler); ok {
+		r.Mount("/blobs", http.StripPrefix("/blobs", server))
+	}
+
 	//API routes with authentication
 	r.Route("/api", func(r chi.Router) {
-		r.Use(auth.Middleware)
+		r.Use(auth.Middleware(verifier, users))
+
+		// Current user settings
+		r.Get("/me", h.GetCurrentUser)
+		r.Put("/me", h.UpdateCurrentUser)
 
 		//Challenges
 		r.Route("/challenges", func(r chi.Router) {
-			r.Get("/", handlers.GetChallenges)
-			r.Post("/", handlers.CreateChallenge)
+			r.Get("/", h.GetChallenges)
+			r.Post("/", h.CreateChallenge)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetChallenge)
+				r.Put("/", h.UpdateChallenge)
+				r.Delete("/", h.DeleteChallenge)
+				r.Post("/reset", h.ResetChallenge)
+				r.Get("/attempts", h.GetChallengeAttempts)
+				r.Post("/clone", h.CloneChallenge)
+				r.Get("/progress", h.GetChallengeProgress)
+				r.Get("/days/{day}/agenda", h.GetDayAgenda)
+				r.Get("/photos/timeline", h.GetPhotoTimeline)
+				r.Get("/photos/compare", h.ComparePhotos)
+			})
+		})
+
+		// Templates
+		r.Route("/templates", func(r chi.Router) {
+			r.Get("/", h.ListTemplates)
+			r.Post("/", h.CreateTemplate)
 
 			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", handlers.GetChallenge)
-				r.Put("/", handlers.UpdateChallenge)
-				r.Delete("/", handlers.DeleteChallenge)
-				r.Post("/reset", handlers.ResetChallenge)
-				r.Get("/progress", handlers.GetChallengeProgress)
+				r.Get("/", h.GetTemplate)
+				r.Delete("/", h.DeleteTemplate)
+				r.Post("/instantiate", h.InstantiateTemplate)
 			})
 		})
 
 		//Sections
 		r.Route("/challenges/{challengeId}/sections", func(r chi.Router) {
-			r.Get("/", handlers.GetSections)
-			r.Post("/", handlers.CreateSection)
+			r.Get("/", h.GetSections)
+			r.Post("/", h.CreateSection)
+			r.Put("/order", h.ReorderSections)
 		})
 
 		r.Route("/sections/{id}", func(r chi.Router) {
-			r.Put("/", handlers.UpdateSection)
-			r.Delete("/", handlers.DeleteSection)
-			r.Put("/order", handlers.ReorderSection)
+			r.Put("/", h.UpdateSection)
+			r.Delete("/", h.DeleteSection)
+			r.Put("/order", h.ReorderSection)
+			r.Post("/move", h.MoveSection)
 		})
 
 		// Tasks
 		r.Route("/sections/{sectionId}/tasks", func(r chi.Router) {
-			r.Get("/", handlers.GetTasks)
-			r.Post("/", handlers.CreateTask)
+			r.Get("/", h.GetTasks)
+			r.Post("/", h.CreateTask)
+			r.Put("/order", h.ReorderTasks)
 		})
 
 		r.Route("/tasks/{id}", func(r chi.Router) {
-			r.Put("/", handlers.UpdateTask)
-			r.Delete("/", handlers.DeleteTask)
-			r.Put("/order", handlers.ReorderTask)
+			r.Put("/", h.UpdateTask)
+			r.Delete("/", h.DeleteTask)
+			r.Put("/order", h.ReorderTask)
+			r.Post("/move", h.MoveTask)
 		})
 		// Daily Entries
 		r.Route("/challenges/{challengeId}/entries", func(r chi.Router) {
-			r.Get("/", handlers.GetDailyEntries)
-			r.Post("/", handlers.CreateOrUpdateTodayEntry)
+			r.Get("/", h.GetDailyEntries)
+			r.Post("/", h.CreateOrUpdateTodayEntry)
 
 			r.Route("/{day}", func(r chi.Router) {
-				r.Get("/", handlers.GetDailyEntry)
-				r.Put("/", handlers.UpdateDailyEntry)
+				r.Get("/", h.GetDailyEntry)
+				r.Put("/", h.UpdateDailyEntry)
+				r.Post("/photos", h.UploadEntryPhoto)
+				r.Post("/attachments", h.CreateAttachment)
 			})
 		})
 
+		r.Route("/attachments/{id}", func(r chi.Router) {
+			r.Delete("/", h.DeleteAttachment)
+		})
+
 		// Measurements
 		r.Route("/challenges/{challengeId}/measurements", func(r chi.Router) {
-			r.Get("/", handlers.GetMeasurements)
-			r.Post("/", handlers.AddMeasurement)
+			r.Get("/", h.GetMeasurements)
+			r.Post("/", h.AddMeasurement)
 		})
 
 		r.Route("/measurements/{id}", func(r chi.Router) {
-			r.Put("/", handlers.UpdateMeasurement)
+			r.Put("/", h.UpdateMeasurement)
+			r.Patch("/", h.PatchMeasurement)
+			r.Delete("/", h.DeleteMeasurement)
 		})
 
 	})
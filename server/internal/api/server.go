@@ -5,19 +5,28 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/blob"
+	"github.com/hari4698/hardinfinity/internal/handlers"
+	"github.com/hari4698/hardinfinity/internal/rollover"
+	"github.com/hari4698/hardinfinity/internal/store"
 )
 
 type Server struct {
-	server *http.Server
+	server   *http.Server
+	rollover *rollover.Scheduler
 }
 
-func NewServer() *Server {
+// NewServer builds the HTTP server, keeping uploads in blobs. The rollover
+// scheduler, if given, is stopped together with the server.
+func NewServer(verifier auth.Verifier, s store.Store, blobs blob.Store, scheduler *rollover.Scheduler) *Server {
 	port := os.Getenv("Port")
 	if port == "" {
 		port = "8080"
 	}
 
-	router := Routes()
+	router := Routes(verifier, s, blobs, handlers.New(s, blobs))
 
 	srv := &http.Server{
 		Addr:         ":" + port,
@@ -28,7 +37,8 @@ func NewServer() *Server {
 	}
 
 	return &Server{
-		server: srv,
+		server:   srv,
+		rollover: scheduler,
 	}
 }
 
@@ -37,11 +47,17 @@ func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
-// Shutdown gracefully stops the server
+// Shutdown gracefully stops the server and the rollover scheduler
 func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
+	if s.rollover != nil {
+		if err := s.rollover.Stop(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Attempt to gracefully shut down the server
 	return s.server.Shutdown(ctx)
 
@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+const (
+	// jwksTTL is how long a fetched key set is trusted before a refresh
+	jwksTTL = time.Hour
+	// jwksMinRefresh throttles refreshes triggered by unknown key IDs
+	jwksMinRefresh = time.Minute
+)
+
+// ClerkVerifier verifies Clerk session tokens against a cached JWKS. The key
+// set is refreshed hourly, or sooner when a token names a key we haven't seen.
+type ClerkVerifier struct {
+	client            clerk.Client
+	issuer            string
+	authorizedParties map[string]struct{}
+	jwks              func() (*clerk.JWKS, error)
+	now               func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]jose.JSONWebKey
+	fetchedAt time.Time
+}
+
+// NewClerkVerifier creates a verifier backed by a single Clerk API client.
+// CLERK_ISSUER and CLERK_AUTHORIZED_PARTIES optionally tighten validation.
+func NewClerkVerifier(secretKey string) (*ClerkVerifier, error) {
+	if secretKey == "" {
+		return nil, fmt.Errorf("CLERK_SECRET_KEY is required for the clerk verifier")
+	}
+
+	client, err := clerk.NewClient(secretKey)
+	if err != nil {
+		return nil, fmt.Errorf("create clerk client: %w", err)
+	}
+
+	v := &ClerkVerifier{
+		client:            client,
+		issuer:            os.Getenv("CLERK_ISSUER"),
+		authorizedParties: map[string]struct{}{},
+		jwks:              client.JWKS().ListAll,
+		now:               time.Now,
+		keys:              map[string]jose.JSONWebKey{},
+	}
+
+	for _, party := range strings.Split(os.Getenv("CLERK_AUTHORIZED_PARTIES"), ",") {
+		if party = strings.TrimSpace(party); party != "" {
+			v.authorizedParties[party] = struct{}{}
+		}
+	}
+
+	return v, nil
+}
+
+// Verify implements Verifier
+func (v *ClerkVerifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil || len(parsed.Headers) == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	header := parsed.Headers[0]
+	if header.KeyID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := v.key(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Algorithm != key.Algorithm {
+		return nil, ErrInvalidToken
+	}
+
+	var registered jwt.Claims
+	var session struct {
+		AuthorizedParty string `json:"azp"`
+	}
+	var profile profileClaims
+	if err := parsed.Claims(key.Key, &registered, &session, &profile); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := validateClaims(registered, v.issuer); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if session.AuthorizedParty != "" && len(v.authorizedParties) > 0 {
+		if _, ok := v.authorizedParties[session.AuthorizedParty]; !ok {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	return &Claims{
+		Subject: registered.Subject,
+		Email:   profile.Email,
+		Name:    profile.Name,
+		Roles:   profile.Roles,
+	}, nil
+}
+
+// LoadProfile implements ProfileLoader using the Clerk users API
+func (v *ClerkVerifier) LoadProfile(ctx context.Context, subject string) (string, string, error) {
+	user, err := v.client.Users().Read(subject)
+	if err != nil {
+		return "", "", fmt.Errorf("fetch clerk user %s: %w", subject, err)
+	}
+
+	email, name := UserProfile(user)
+	return email, name, nil
+}
+
+// key returns the signing key for kid, refreshing the cache when it is stale
+// or when kid is unknown (Clerk rotated its keys)
+func (v *ClerkVerifier) key(kid string) (jose.JSONWebKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	age := v.now().Sub(v.fetchedAt)
+	fresh := age < jwksTTL
+	recent := age < jwksMinRefresh
+	v.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if !ok && recent {
+		return jose.JSONWebKey{}, ErrInvalidToken
+	}
+
+	if err := v.refresh(); err != nil {
+		// Keep serving a stale but known key rather than failing every request
+		if ok {
+			return key, nil
+		}
+		return jose.JSONWebKey{}, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	if !ok {
+		return jose.JSONWebKey{}, ErrInvalidToken
+	}
+
+	return key, nil
+}
+
+func (v *ClerkVerifier) refresh() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Another request may have refreshed while we waited for the lock
+	if v.now().Sub(v.fetchedAt) < jwksMinRefresh {
+		return nil
+	}
+
+	jwks, err := v.jwks()
+	if err != nil {
+		return fmt.Errorf("fetch clerk jwks: %w", err)
+	}
+
+	keys := make(map[string]jose.JSONWebKey, len(jwks.Keys))
+	for _, key := range jwks.Keys {
+		keys[key.KeyID] = key
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+	return nil
+}
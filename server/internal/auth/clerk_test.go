@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+// testKey is a signing key of the fake Clerk instance
+type testKey struct {
+	kid     string
+	private *rsa.PrivateKey
+}
+
+func newTestKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: kid, private: private}
+}
+
+func (k testKey) public() jose.JSONWebKey {
+	return jose.JSONWebKey{Key: &k.private.PublicKey, KeyID: k.kid, Algorithm: string(jose.RS256), Use: "sig"}
+}
+
+// token signs a session token for subject, with extra claims merged in
+func (k testKey) token(t *testing.T, subject string, extra map[string]any) string {
+	t.Helper()
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: k.private, KeyID: k.kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := jwt.Claims{
+		Issuer:    "https://clerk.example.com",
+		Subject:   subject,
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now.Add(-time.Minute)),
+		Expiry:    jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+	token, err := jwt.Signed(signer).Claims(claims).Claims(extra).CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// fakeJWKS is an in-memory key set standing in for Clerk's JWKS endpoint
+type fakeJWKS struct {
+	keys    []jose.JSONWebKey
+	err     error
+	fetches int
+}
+
+func (f *fakeJWKS) list() (*clerk.JWKS, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &clerk.JWKS{Keys: append([]jose.JSONWebKey(nil), f.keys...)}, nil
+}
+
+// testClock is a clock the test moves forward by hand
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time          { return c.t }
+func (c *testClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestVerifier(jwks *fakeJWKS, clock *testClock) *ClerkVerifier {
+	return &ClerkVerifier{
+		issuer:            "https://clerk.example.com",
+		authorizedParties: map[string]struct{}{"https://app.example.com": {}},
+		jwks:              jwks.list,
+		now:               clock.now,
+		keys:              map[string]jose.JSONWebKey{},
+	}
+}
+
+func TestClerkVerifierClaims(t *testing.T) {
+	key := newTestKey(t, "ins_1")
+	stranger := newTestKey(t, "ins_1")
+	jwks := &fakeJWKS{keys: []jose.JSONWebKey{key.public()}}
+	v := newTestVerifier(jwks, &testClock{t: time.Now()})
+
+	expired := func() string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key.private, KeyID: key.kid}}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:  "https://clerk.example.com",
+			Subject: "user_1",
+			Expiry:  jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+		}).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}()
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Claims
+		wantErr bool
+	}{
+		{
+			name:  "valid",
+			token: key.token(t, "user_1", nil),
+			want:  &Claims{Subject: "user_1"},
+		},
+		{
+			name: "profile claims",
+			token: key.token(t, "user_1", map[string]any{
+				"email": "ada@example.com", "name": "Ada", "roles": []string{"admin"}, "azp": "https://app.example.com",
+			}),
+			want: &Claims{Subject: "user_1", Email: "ada@example.com", Name: "Ada", Roles: []string{"admin"}},
+		},
+		{name: "other issuer", token: key.token(t, "user_1", map[string]any{"iss": "https://evil.example.com"}), wantErr: true},
+		{name: "unauthorized party", token: key.token(t, "user_1", map[string]any{"azp": "https://evil.example.com"}), wantErr: true},
+		{name: "no subject", token: key.token(t, "", nil), wantErr: true},
+		{name: "expired", token: expired, wantErr: true},
+		{name: "signed by another key with the same kid", token: stranger.token(t, "user_1", nil), wantErr: true},
+		{name: "not a token", token: "not.a.token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify = %+v, %v, want ErrInvalidToken", claims, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != tt.want.Subject || claims.Email != tt.want.Email || claims.Name != tt.want.Name ||
+				len(claims.Roles) != len(tt.want.Roles) {
+				t.Errorf("Verify = %+v, want %+v", claims, tt.want)
+			}
+		})
+	}
+}
+
+func TestClerkVerifierKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	oldKey := newTestKey(t, "ins_old")
+	newKey := newTestKey(t, "ins_new")
+
+	jwks := &fakeJWKS{keys: []jose.JSONWebKey{oldKey.public()}}
+	clock := &testClock{t: time.Now()}
+	v := newTestVerifier(jwks, clock)
+
+	verify := func(key testKey) error {
+		_, err := v.Verify(ctx, key.token(t, "user_1", nil))
+		return err
+	}
+
+	// The key set is fetched on first use and then cached
+	if err := verify(oldKey); err != nil {
+		t.Fatalf("old key before rotation: %v", err)
+	}
+	if err := verify(oldKey); err != nil || jwks.fetches != 1 {
+		t.Fatalf("cached old key: err = %v, fetches = %d, want nil, 1", err, jwks.fetches)
+	}
+
+	// Clerk rotates in a new key. Unknown key IDs trigger a refresh, but no
+	// more than once a minute.
+	jwks.keys = []jose.JSONWebKey{oldKey.public(), newKey.public()}
+	if err := verify(newKey); !errors.Is(err, ErrInvalidToken) || jwks.fetches != 1 {
+		t.Fatalf("new key right after a fetch: err = %v, fetches = %d, want ErrInvalidToken, 1", err, jwks.fetches)
+	}
+
+	clock.advance(jwksMinRefresh)
+	if err := verify(newKey); err != nil || jwks.fetches != 2 {
+		t.Fatalf("new key after the refresh throttle: err = %v, fetches = %d, want nil, 2", err, jwks.fetches)
+	}
+	if err := verify(oldKey); err != nil || jwks.fetches != 2 {
+		t.Fatalf("old key during rotation: err = %v, fetches = %d, want nil, 2", err, jwks.fetches)
+	}
+
+	// The old key is retired; it keeps working until the cache expires
+	jwks.keys = []jose.JSONWebKey{newKey.public()}
+	clock.advance(jwksTTL - time.Second)
+	if err := verify(oldKey); err != nil || jwks.fetches != 2 {
+		t.Fatalf("retired key before the TTL: err = %v, fetches = %d, want nil, 2", err, jwks.fetches)
+	}
+
+	clock.advance(time.Second)
+	if err := verify(oldKey); !errors.Is(err, ErrInvalidToken) || jwks.fetches != 3 {
+		t.Fatalf("retired key after the TTL: err = %v, fetches = %d, want ErrInvalidToken, 3", err, jwks.fetches)
+	}
+	if err := verify(newKey); err != nil || jwks.fetches != 3 {
+		t.Fatalf("new key after the TTL: err = %v, fetches = %d, want nil, 3", err, jwks.fetches)
+	}
+}
+
+func TestClerkVerifierFetchFailure(t *testing.T) {
+	ctx := context.Background()
+	key := newTestKey(t, "ins_1")
+	other := newTestKey(t, "ins_2")
+
+	jwks := &fakeJWKS{err: errors.New("clerk is down")}
+	clock := &testClock{t: time.Now()}
+	v := newTestVerifier(jwks, clock)
+
+	// Without any cached keys a failed fetch fails verification
+	if _, err := v.Verify(ctx, key.token(t, "user_1", nil)); err == nil || errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify without keys = %v, want the fetch error", err)
+	}
+
+	jwks.err = nil
+	jwks.keys = []jose.JSONWebKey{key.public()}
+	clock.advance(jwksMinRefresh)
+	if _, err := v.Verify(ctx, key.token(t, "user_1", nil)); err != nil {
+		t.Fatalf("Verify after recovery: %v", err)
+	}
+
+	// Once the cache is stale, a known key is still served while Clerk is down
+	jwks.err = errors.New("clerk is down again")
+	clock.advance(jwksTTL)
+	if _, err := v.Verify(ctx, key.token(t, "user_1", nil)); err != nil {
+		t.Errorf("Verify with a stale key: %v", err)
+	}
+	if _, err := v.Verify(ctx, other.token(t, "user_1", nil)); err == nil {
+		t.Error("Verify with an unknown key while Clerk is down succeeded")
+	}
+}
+
+func TestClerkVerifierAlgorithmMismatch(t *testing.T) {
+	key := newTestKey(t, "ins_1")
+	published := key.public()
+	published.Algorithm = string(jose.RS512)
+	v := newTestVerifier(&fakeJWKS{keys: []jose.JSONWebKey{published}}, &testClock{t: time.Now()})
+
+	if _, err := v.Verify(context.Background(), key.token(t, "user_1", nil)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify = %v, want ErrInvalidToken", err)
+	}
+}
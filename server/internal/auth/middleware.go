@@ -2,58 +2,87 @@ package auth
 
 import (
 	"context"
+	"log"
 	"net/http"
-	"os"
 	"strings"
 
-	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/store"
 )
 
 type contextKey string
-const UserIDKey contextKey = "userID"
-
-func Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get the Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
-		}
 
-		// Extract the token
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
-		}
-		token := parts[1]
+const principalKey contextKey = "principal"
 
-		// Initialize Clerk client
-		client, err := clerk.NewClient(os.Getenv("CLERK_SECRET_KEY"))
-		if err != nil {
-			http.Error(w, "Failed to initialize auth client", http.StatusInternalServerError)
-			return
-		}
+// Principal is the authenticated caller, resolved once per request
+type Principal struct {
+	UserID  uuid.UUID `json:"user_id"`
+	ClerkID string    `json:"clerk_id"`
+	Email   string    `json:"email"`
+	Roles   []string  `json:"roles"`
+	// Timezone is the user's IANA timezone
+	Timezone string `json:"timezone"`
+}
 
-		// Verify the session
-		claims, err := client.VerifyToken(token)
-		if err != nil {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
 		}
+	}
+	return false
+}
+
+// Middleware authenticates requests with the given verifier and resolves the
+// caller to a user of users
+func Middleware(verifier Verifier, users store.UserStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get the Authorization header
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
+			}
 
-		// Extract user ID from claims
-		userID := claims.Subject
+			// Extract the token
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
+				return
+			}
+			token := parts[1]
 
-		// Add user ID to context
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			// Verify the session
+			claims, err := verifier.Verify(r.Context(), token)
+			if err != nil {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			// Resolve the internal user, provisioning it if the webhook hasn't yet
+			principal, err := resolvePrincipal(r.Context(), users, verifier, claims)
+			if err != nil {
+				log.Printf("Failed to resolve user %s: %v", claims.Subject, err)
+				http.Error(w, "Failed to resolve user", http.StatusInternalServerError)
+				return
+			}
+
+			// Add the principal to context
+			ctx := WithPrincipal(r.Context(), principal)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-// GetUserID retrieves the user ID from the context
-func GetUserID(ctx context.Context) (string, bool) {
-	userID, ok := ctx.Value(UserIDKey).(string)
-	return userID, ok
-}
\ No newline at end of file
+// WithPrincipal returns a copy of ctx carrying principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// PrincipalFromContext retrieves the authenticated principal from the context
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// UserProfile returns the primary email and display name for a Clerk user
+func UserProfile(user *clerk.User) (email, name string) {
+	for _, address := range user.EmailAddresses {
+		if user.PrimaryEmailAddressID != nil && address.ID == *user.PrimaryEmailAddressID {
+			email = address.EmailAddress
+			break
+		}
+	}
+	if email == "" && len(user.EmailAddresses) > 0 {
+		email = user.EmailAddresses[0].EmailAddress
+	}
+
+	var parts []string
+	if user.FirstName != nil && *user.FirstName != "" {
+		parts = append(parts, *user.FirstName)
+	}
+	if user.LastName != nil && *user.LastName != "" {
+		parts = append(parts, *user.LastName)
+	}
+	name = strings.Join(parts, " ")
+
+	if name == "" && user.Username != nil {
+		name = *user.Username
+	}
+	if name == "" {
+		name = email
+	}
+
+	return email, name
+}
+
+// resolvePrincipal looks up the internal user for the token subject, creating
+// it when missing. Webhooks are the primary provisioning path; this covers
+// requests that arrive before the user.created delivery does.
+func resolvePrincipal(ctx context.Context, users store.UserStore, verifier Verifier, claims *Claims) (*Principal, error) {
+	principal := &Principal{ClerkID: claims.Subject, Roles: claims.Roles, Timezone: dates.DefaultTimezone}
+
+	user, err := users.GetUserByClerkID(ctx, claims.Subject)
+	if err == nil {
+		principal.UserID, principal.Email, principal.Timezone = user.ID, user.Email, user.Timezone
+		return principal, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("lookup user %s: %w", claims.Subject, err)
+	}
+
+	email, name := claims.Email, claims.Name
+
+	// Clerk session tokens don't carry the email by default, so fall back to
+	// the verifier's profile lookup when it has one
+	if email == "" {
+		loader, ok := verifier.(ProfileLoader)
+		if !ok {
+			return nil, fmt.Errorf("token for %s has no email claim", claims.Subject)
+		}
+
+		email, name, err = loader.LoadProfile(ctx, claims.Subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if email == "" {
+		return nil, fmt.Errorf("user %s has no email address", claims.Subject)
+	}
+	if name == "" {
+		name = email
+	}
+
+	user, err = users.UpsertUser(ctx, claims.Subject, email, name)
+	if err != nil {
+		return nil, fmt.Errorf("upsert user %s: %w", claims.Subject, err)
+	}
+	principal.UserID, principal.Email, principal.Timezone = user.ID, user.Email, user.Timezone
+
+	return principal, nil
+}
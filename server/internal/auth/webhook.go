@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// webhookTolerance is how far a Svix timestamp may drift from the server clock
+const webhookTolerance = 5 * time.Minute
+
+var (
+	ErrMissingWebhookHeaders = errors.New("missing svix headers")
+	ErrWebhookTimestamp      = errors.New("webhook timestamp outside tolerance")
+	ErrWebhookSignature      = errors.New("no matching webhook signature")
+)
+
+// VerifyWebhook checks the Svix signature headers Clerk attaches to webhook
+// deliveries. The secret is the "whsec_" value from the Clerk dashboard.
+func VerifyWebhook(secret string, header http.Header, payload []byte) error {
+	return verifyWebhook(secret, header, payload, time.Now())
+}
+
+// verifyWebhook is VerifyWebhook as of now
+func verifyWebhook(secret string, header http.Header, payload []byte, now time.Time) error {
+	msgID := header.Get("svix-id")
+	msgTimestamp := header.Get("svix-timestamp")
+	msgSignature := header.Get("svix-signature")
+	if msgID == "" || msgTimestamp == "" || msgSignature == "" {
+		return ErrMissingWebhookHeaders
+	}
+
+	// Reject stale or future deliveries to limit replay attacks
+	ts, err := strconv.ParseInt(msgTimestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookTimestamp
+	}
+	sent := time.Unix(ts, 0)
+	if now.Sub(sent) > webhookTolerance || sent.Sub(now) > webhookTolerance {
+		return ErrWebhookTimestamp
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, "whsec_"))
+	if err != nil {
+		return fmt.Errorf("invalid webhook secret: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msgID + "." + msgTimestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	// The header holds space separated "version,signature" pairs
+	for _, versioned := range strings.Split(msgSignature, " ") {
+		version, signature, found := strings.Cut(versioned, ",")
+		if !found || version != "v1" {
+			continue
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(signature)
+		if err != nil {
+			continue
+		}
+
+		if hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+
+	return ErrWebhookSignature
+}
@@ -0,0 +1,216 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// The example delivery from the Svix webhook verification docs
+const (
+	exampleSecret    = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	exampleID        = "msg_p5jXN8AQM9LWM0D4loKWxJek"
+	exampleTimestamp = "1614265330"
+	examplePayload   = `{"test": 2432232314}`
+	exampleSignature = "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+)
+
+var exampleSent = time.Unix(1614265330, 0)
+
+func webhookHeader(id, timestamp, signature string) http.Header {
+	h := http.Header{}
+	if id != "" {
+		h.Set("svix-id", id)
+	}
+	if timestamp != "" {
+		h.Set("svix-timestamp", timestamp)
+	}
+	if signature != "" {
+		h.Set("svix-signature", signature)
+	}
+	return h
+}
+
+// sign returns the v1 signature of a delivery
+func sign(secret, id, timestamp, payload string) string {
+	key, err := base64.StdEncoding.DecodeString(secret[len("whsec_"):])
+	if err != nil {
+		panic(err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + timestamp + "." + payload))
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	otherSecret := "whsec_" + base64.StdEncoding.EncodeToString([]byte("another webhook signing secret"))
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  http.Header
+		payload string
+		now     time.Time
+		want    error
+	}{
+		{
+			name:    "valid",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+		},
+		{
+			name:    "secret without prefix",
+			secret:  exampleSecret[len("whsec_"):],
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+		},
+		{
+			name:    "one of several signatures matches",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, "v1,Zm9v v2,ignored "+exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+		},
+		{
+			name:    "just inside the tolerance",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent.Add(webhookTolerance),
+		},
+		{
+			name:    "too old",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent.Add(webhookTolerance + time.Second),
+			want:    ErrWebhookTimestamp,
+		},
+		{
+			name:    "too far in the future",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent.Add(-webhookTolerance - time.Second),
+			want:    ErrWebhookTimestamp,
+		},
+		{
+			name:    "timestamp not a number",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, "yesterday", exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookTimestamp,
+		},
+		{
+			name:    "tampered payload",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, exampleSignature),
+			payload: `{"test": 2432232315}`,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "tampered message ID",
+			secret:  exampleSecret,
+			header:  webhookHeader("msg_other", exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "replayed with a new timestamp",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, strconv.FormatInt(exampleSent.Unix()+60, 10), exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "signed with another secret",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, sign(otherSecret, exampleID, exampleTimestamp, examplePayload)),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "unknown signature version",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, "v2"+exampleSignature[2:]),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "signature not base64",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, "v1,!!!"),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrWebhookSignature,
+		},
+		{
+			name:    "missing ID",
+			secret:  exampleSecret,
+			header:  webhookHeader("", exampleTimestamp, exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrMissingWebhookHeaders,
+		},
+		{
+			name:    "missing timestamp",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, "", exampleSignature),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrMissingWebhookHeaders,
+		},
+		{
+			name:    "missing signature",
+			secret:  exampleSecret,
+			header:  webhookHeader(exampleID, exampleTimestamp, ""),
+			payload: examplePayload,
+			now:     exampleSent,
+			want:    ErrMissingWebhookHeaders,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyWebhook(tt.secret, tt.header, []byte(tt.payload), tt.now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("verifyWebhook = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookInvalidSecret(t *testing.T) {
+	header := webhookHeader(exampleID, exampleTimestamp, exampleSignature)
+	err := verifyWebhook("whsec_not base64!", header, []byte(examplePayload), exampleSent)
+	if err == nil || errors.Is(err, ErrWebhookSignature) {
+		t.Errorf("verifyWebhook = %v, want an invalid secret error", err)
+	}
+}
+
+func TestVerifyWebhookUsesTheClock(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	header := webhookHeader(exampleID, now, sign(exampleSecret, exampleID, now, examplePayload))
+	if err := VerifyWebhook(exampleSecret, header, []byte(examplePayload)); err != nil {
+		t.Errorf("VerifyWebhook of a fresh delivery = %v, want nil", err)
+	}
+
+	header = webhookHeader(exampleID, exampleTimestamp, exampleSignature)
+	if err := VerifyWebhook(exampleSecret, header, []byte(examplePayload)); !errors.Is(err, ErrWebhookTimestamp) {
+		t.Errorf("VerifyWebhook of a 2021 delivery = %v, want %v", err, ErrWebhookTimestamp)
+	}
+}
@@ -0,0 +1,65 @@
+// Package blob stores uploaded files such as progress photos. Blobs are never
+// served publicly: clients get signed URLs that expire.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when a blob does not exist
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash-separated keys such as
+// "challenges/<id>/days/3/<id>.jpg"
+type Store interface {
+	// Put stores size bytes read from r under key, replacing any blob there
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the content of the blob under key
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the blob under key. Deleting a missing blob succeeds.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that serves the blob under key until ttl has
+	// passed
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// NewFromEnv configures the store named by BLOB_STORE. "local", the default,
+// keeps blobs under BLOB_DIR and signs URLs to PUBLIC_URL with
+// BLOB_SIGNING_KEY. "s3" uses the bucket S3_BUCKET at S3_ENDPOINT, which may
+// be any S3-compatible service such as MinIO.
+func NewFromEnv() (Store, error) {
+	switch mode := os.Getenv("BLOB_STORE"); mode {
+	case "", "local":
+		dir := os.Getenv("BLOB_DIR")
+		if dir == "" {
+			dir = "data/blobs"
+		}
+		return NewLocal(dir, os.Getenv("PUBLIC_URL"), []byte(os.Getenv("BLOB_SIGNING_KEY")))
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown BLOB_STORE %q", mode)
+	}
+}
+
+// validKey rejects keys that could escape the store, such as absolute paths
+// or paths containing ".."
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
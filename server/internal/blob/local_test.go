@@ -0,0 +1,200 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLocal returns a store holding one blob under "days/1/photo.jpg",
+// with its clock fixed at now
+func newTestLocal(t *testing.T, now time.Time) *Local {
+	t.Helper()
+
+	l, err := NewLocal(t.TempDir(), "https://api.example.com/", []byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.now = func() time.Time { return now }
+
+	content := "photo content"
+	if err := l.Put(context.Background(), "days/1/photo.jpg", strings.NewReader(content), int64(len(content)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// serve requests a signed URL from l as the API does, with /blobs stripped
+func serve(l *Local, signed string) *httptest.ResponseRecorder {
+	u, err := url.Parse(signed)
+	if err != nil {
+		panic(err)
+	}
+	u.Path = strings.TrimPrefix(u.Path, "/blobs")
+
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	return rec
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLocal(t, now)
+
+	signed, err := l.SignedURL(context.Background(), "days/1/photo.jpg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(signed, "https://api.example.com/blobs/days/1/photo.jpg?") {
+		t.Fatalf("SignedURL = %s, want it under https://api.example.com/blobs/", signed)
+	}
+
+	rec := serve(l, signed)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if body := rec.Body.String(); body != "photo content" {
+		t.Errorf("body = %q, want %q", body, "photo content")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "private, max-age=3600" {
+		t.Errorf("Cache-Control = %q, want private, max-age=3600", cc)
+	}
+}
+
+func TestLocalSignedURLExpiry(t *testing.T) {
+	issued := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"just issued", issued, http.StatusOK},
+		{"at expiry", issued.Add(time.Hour), http.StatusOK},
+		{"a second after expiry", issued.Add(time.Hour + time.Second), http.StatusForbidden},
+		{"a day after expiry", issued.Add(25 * time.Hour), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newTestLocal(t, issued)
+			signed, err := l.SignedURL(context.Background(), "days/1/photo.jpg", time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			l.now = func() time.Time { return tt.at }
+			if rec := serve(l, signed); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalSignedURLTampering(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := newTestLocal(t, now)
+
+	other := "other content"
+	if err := l.Put(context.Background(), "days/2/photo.jpg", strings.NewReader(other), int64(len(other)), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := l.SignedURL(context.Background(), "days/1/photo.jpg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := u.Query()
+
+	tests := []struct {
+		name   string
+		tamper func(u *url.URL, q url.Values)
+	}{
+		{"other key", func(u *url.URL, q url.Values) { u.Path = "/blobs/days/2/photo.jpg" }},
+		{"extended expiry", func(u *url.URL, q url.Values) { q.Set("expires", "9999999999") }},
+		{"missing expiry", func(u *url.URL, q url.Values) { q.Del("expires") }},
+		{"changed signature", func(u *url.URL, q url.Values) { q.Set("signature", strings.Repeat("A", len(q.Get("signature")))) }},
+		{"missing signature", func(u *url.URL, q url.Values) { q.Del("signature") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := *u
+			q := url.Values{}
+			for k, v := range query {
+				q[k] = append([]string(nil), v...)
+			}
+			tt.tamper(&tampered, q)
+			tampered.RawQuery = q.Encode()
+
+			if rec := serve(l, tampered.String()); rec.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", rec.Code)
+			}
+		})
+	}
+
+	// A URL signed by another secret is rejected as well
+	forger, err := NewLocal(t.TempDir(), "https://api.example.com", []byte("another-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forger.now = l.now
+	forged, err := forger.SignedURL(context.Background(), "days/1/photo.jpg", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(l, forged); rec.Code != http.StatusForbidden {
+		t.Errorf("URL signed with another key: status = %d, want 403", rec.Code)
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	l := newTestLocal(t, time.Now())
+
+	rc, err := l.Open(ctx, "days/1/photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "photo content" {
+		t.Fatalf("Open = %q, %v, want %q", data, err, "photo content")
+	}
+
+	if err := l.Put(ctx, "days/1/short.jpg", strings.NewReader("abc"), 10, "image/jpeg"); err == nil {
+		t.Error("Put with a short body succeeded, want an error")
+	}
+	if _, err := l.Open(ctx, "days/1/short.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after a failed Put: err = %v, want ErrNotFound", err)
+	}
+
+	if err := l.Delete(ctx, "days/1/photo.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Open(ctx, "days/1/photo.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := l.Delete(ctx, "days/1/photo.jpg"); err != nil {
+		t.Errorf("Delete of a missing blob: err = %v, want nil", err)
+	}
+
+	for _, key := range []string{"", "/etc/passwd", "../escape.jpg", "days/../../escape.jpg"} {
+		if _, err := l.Open(ctx, key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q): err = %v, want an invalid key error", key, err)
+		}
+	}
+}
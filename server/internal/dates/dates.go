@@ -0,0 +1,60 @@
+// Package dates works with the calendar dates challenges are measured in.
+// A date is a time.Time at midnight UTC, which is how Postgres DATE columns
+// are scanned, so dates from either side compare directly.
+package dates
+
+import "time"
+
+// DefaultTimezone is used for users that have not picked a timezone
+const DefaultTimezone = "UTC"
+
+// Location loads an IANA timezone, falling back to UTC for an empty or
+// unknown name
+func Location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Valid reports whether name is an IANA timezone
+func Valid(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// Of returns the calendar date of t as seen in loc
+func Of(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the current calendar date in the named timezone
+func Today(timezone string) time.Time {
+	return Of(time.Now(), Location(timezone))
+}
+
+// EndOf returns the instant the date d ends in loc, i.e. midnight of the
+// following day
+func EndOf(d time.Time, loc *time.Location) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day()+1, 0, 0, 0, 0, loc)
+}
+
+// Between counts calendar days from a to b, ignoring the time of day
+func Between(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// Format renders a date as YYYY-MM-DD
+func Format(d time.Time) string {
+	return d.Format(time.DateOnly)
+}
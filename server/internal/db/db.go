@@ -8,28 +8,18 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-var DB *pgxpool.Pool
-
-func Initialize() error {
-	ctx := context.Background()
-	
-	connStr := os.Getenv("DATABASE_URL")
-	
-	var err error
-	DB, err = pgxpool.New(ctx, connStr)
+// Connect opens a connection pool to DATABASE_URL and checks that the
+// database is reachable
+func Connect(ctx context.Context) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, os.Getenv("DATABASE_URL"))
 	if err != nil {
-		return fmt.Errorf("unable to connect to database: %w", err)
-	}
-	
-	if err := DB.Ping(ctx); err != nil {
-		return fmt.Errorf("unable to ping the database: %w", err)
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
-	
-	return nil
-}
 
-func Close() {
-	if DB != nil {
-		DB.Close()
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("unable to ping the database: %w", err)
 	}
-}
\ No newline at end of file
+
+	return pool, nil
+}
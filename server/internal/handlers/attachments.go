@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/imaging"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/rules"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/utils"
+)
+
+const (
+	// maxPhotoBytes caps the size of an uploaded photo
+	maxPhotoBytes = 10 << 20
+	// photoURLTTL is how long a signed photo URL stays valid
+	photoURLTTL = 15 * time.Minute
+	// maxCaptionLength limits attachment captions, in characters
+	maxCaptionLength = 500
+)
+
+// Attachment kinds
+const (
+	kindProgressPhoto = "progress_photo"
+	kindPhoto         = "photo"
+	kindScreenshot    = "screenshot"
+)
+
+var attachmentKinds = []string{kindProgressPhoto, kindPhoto, kindScreenshot}
+
+// CreateAttachment attaches the image in the multipart field "file" to a
+// day's entry, or to its entry for the task named by the "task_id" field. The
+// optional "kind" field defaults to photo and "caption" describes the file.
+// The attachment is appended after the existing ones.
+func (h *Handler) CreateAttachment(w http.ResponseWriter, r *http.Request) {
+	h.uploadAttachment(w, r, "file", "")
+}
+
+// UploadEntryPhoto adds the image in the multipart field "photo" to a day's
+// progress photos
+func (h *Handler) UploadEntryPhoto(w http.ResponseWriter, r *http.Request) {
+	h.uploadAttachment(w, r, "photo", kindProgressPhoto)
+}
+
+// uploadAttachment stores the image in the multipart field and attaches it.
+// kind, when set, overrides the "kind" form field. The day's entry is created
+// when it has none yet, flagged as backfilled like a saved entry when the day
+// is already over. Like other edits, uploads are refused once the day is
+// locked.
+func (h *Handler) uploadAttachment(w http.ResponseWriter, r *http.Request, field, kind string) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
+
+	dayNumber, err := strconv.Atoi(chi.URLParam(r, "day"))
+	if err != nil || dayNumber < 1 || dayNumber > challenge.DurationDays {
+		utils.Error(w, http.StatusBadRequest, "Invalid day number")
+		return
+	}
+	now := time.Now()
+	today := dates.Of(now, dates.Location(challenge.EffectiveTimezone))
+	if dayNumber > schedule.CalendarOf(challenge).Day(today) {
+		utils.Error(w, http.StatusBadRequest, "Day has not started yet")
+		return
+	}
+	if rules.Locked(challenge, dayNumber, now) {
+		utils.Error(w, http.StatusForbidden, fmt.Sprintf("Day %d is locked", dayNumber))
+		return
+	}
+
+	data, ok := readPhoto(w, r, field)
+	if !ok {
+		return
+	}
+
+	// The form is parsed by now
+	attachment := models.Attachment{Kind: kind, Caption: r.FormValue("caption")}
+	if attachment.Kind == "" {
+		attachment.Kind = r.FormValue("kind")
+	}
+	if attachment.Kind == "" {
+		attachment.Kind = kindPhoto
+	}
+	var taskID uuid.UUID
+	var fieldErrors []utils.FieldError
+	if !slices.Contains(attachmentKinds, attachment.Kind) {
+		fieldErrors = append(fieldErrors, utils.FieldError{Field: "kind", Message: "must be progress_photo, photo or screenshot"})
+	}
+	if utf8.RuneCountInString(attachment.Caption) > maxCaptionLength {
+		fieldErrors = append(fieldErrors, utils.FieldError{Field: "caption", Message: fmt.Sprintf("must be at most %d characters", maxCaptionLength)})
+	}
+	if v := r.FormValue("task_id"); v != "" {
+		if taskID, err = uuid.Parse(v); err != nil {
+			fieldErrors = append(fieldErrors, utils.FieldError{Field: "task_id", Message: "must be a task ID"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid attachment", fieldErrors)
+		return
+	}
+
+	photo, err := imaging.Prepare(data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupported):
+		utils.Error(w, http.StatusUnsupportedMediaType, "Photo must be a JPEG or PNG image")
+		return
+	case errors.Is(err, imaging.ErrTooLarge):
+		utils.Error(w, http.StatusRequestEntityTooLarge, "Photo has too many pixels")
+		return
+	case err != nil:
+		utils.Error(w, http.StatusBadRequest, "Photo could not be read")
+		return
+	}
+
+	ctx := r.Context()
+	base := fmt.Sprintf("challenges/%s/days/%d/%s", challenge.ID, dayNumber, uuid.New())
+	attachment.BlobKey, attachment.ThumbnailKey = base+".jpg", base+"_thumb.jpg"
+	attachment.MimeType, attachment.Size = imaging.ContentType, int64(len(photo.Image))
+	if err := h.putPhoto(ctx, attachment.BlobKey, attachment.ThumbnailKey, photo); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to store photo")
+		return
+	}
+
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		entry, err := tx.GetEntry(ctx, challenge.ID, dayNumber)
+		if errors.Is(err, store.ErrNotFound) {
+			entry = newEntry(challenge, dayNumber, schedule.CalendarOf(challenge).Date(dayNumber), now)
+			err = tx.CreateEntry(ctx, entry)
+		}
+		if err != nil {
+			return err
+		}
+
+		if taskID == uuid.Nil {
+			attachment.DailyEntryID = &entry.ID
+			return tx.CreateAttachment(ctx, &attachment)
+		}
+
+		taskEntries, err := tx.ListTaskEntries(ctx, entry.ID)
+		if err != nil {
+			return err
+		}
+		for _, te := range taskEntries {
+			if te.TaskID == taskID {
+				attachment.TaskEntryID = &te.ID
+				return tx.CreateAttachment(ctx, &attachment)
+			}
+		}
+		return store.ErrNotFound
+	})
+	if err != nil {
+		h.deleteBlobs(ctx, attachment.BlobKey, attachment.ThumbnailKey)
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Task entry not found")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to save attachment")
+		return
+	}
+
+	created := []models.Attachment{attachment}
+	if err := h.signAttachments(ctx, created); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to sign photo URL")
+		return
+	}
+
+	utils.Success(w, http.StatusCreated, created[0])
+}
+
+// DeleteAttachment removes an attachment along with its stored files
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	attachment, ok := h.ownedAttachment(w, r, principal)
+	if !ok {
+		return
+	}
+
+	challenge, err := h.store.GetChallenge(r.Context(), principal.UserID, attachment.ChallengeID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve challenge")
+		return
+	}
+	if rules.Locked(challenge, attachment.DayNumber, time.Now()) {
+		utils.Error(w, http.StatusForbidden, fmt.Sprintf("Day %d is locked", attachment.DayNumber))
+		return
+	}
+
+	if err := h.store.DeleteAttachment(r.Context(), attachment.ID); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to delete attachment")
+		return
+	}
+	h.deleteBlobs(r.Context(), attachment.BlobKey, attachment.ThumbnailKey)
+
+	utils.Success(w, http.StatusOK, map[string]any{
+		"message": "Attachment deleted successfully",
+		"id":      attachment.ID,
+	})
+}
+
+// ownedAttachment loads the attachment named by the "id" URL parameter and
+// checks that its challenge belongs to the principal
+func (h *Handler) ownedAttachment(w http.ResponseWriter, r *http.Request, principal *auth.Principal) (*models.Attachment, bool) {
+	attachmentID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid attachment ID")
+		return nil, false
+	}
+
+	attachment, err := h.store.GetAttachment(r.Context(), principal.UserID, attachmentID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Attachment not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve attachment")
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// readPhoto reads the file in a multipart field, writing the error response
+// when it is missing or over maxPhotoBytes
+func readPhoto(w http.ResponseWriter, r *http.Request, field string) ([]byte, bool) {
+	// Leave some room for the rest of the multipart body
+	r.Body = http.MaxBytesReader(w, r.Body, maxPhotoBytes+1<<20)
+
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Photo must be at most %d MB", maxPhotoBytes>>20))
+			return nil, false
+		}
+		utils.Error(w, http.StatusBadRequest, fmt.Sprintf("File %q is required", field))
+		return nil, false
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxPhotoBytes+1))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Photo could not be read")
+		return nil, false
+	}
+	if len(data) > maxPhotoBytes {
+		utils.Error(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Photo must be at most %d MB", maxPhotoBytes>>20))
+		return nil, false
+	}
+
+	return data, true
+}
+
+// putPhoto stores a prepared photo and its thumbnail. Nothing is left behind
+// when either fails.
+func (h *Handler) putPhoto(ctx context.Context, photoKey, thumbnailKey string, photo *imaging.Photo) error {
+	if err := h.blobs.Put(ctx, photoKey, bytes.NewReader(photo.Image), int64(len(photo.Image)), imaging.ContentType); err != nil {
+		return err
+	}
+
+	err := h.blobs.Put(ctx, thumbnailKey, bytes.NewReader(photo.Thumbnail), int64(len(photo.Thumbnail)), imaging.ContentType)
+	if err != nil {
+		h.deleteBlobs(ctx, photoKey)
+	}
+	return err
+}
+
+// deleteBlobs removes blobs that are no longer referenced. Failures only
+// leave an orphan behind, so they are logged rather than reported.
+func (h *Handler) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := h.blobs.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+// deleteAttachmentBlobs removes the files of attachments whose rows are gone
+func (h *Handler) deleteAttachmentBlobs(ctx context.Context, attachments []models.Attachment) {
+	for _, a := range attachments {
+		h.deleteBlobs(ctx, a.BlobKey, a.ThumbnailKey)
+	}
+}
+
+// signURL returns a signed URL for key, or "" when key is empty
+func (h *Handler) signURL(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	return h.blobs.SignedURL(ctx, key, photoURLTTL)
+}
+
+// signPhotos points the photo URLs of entries with a progress photo
+// attachment at signed, expiring URLs
+func (h *Handler) signPhotos(ctx context.Context, entries ...*models.DailyEntry) error {
+	for _, entry := range entries {
+		if entry.PhotoKey == "" {
+			continue
+		}
+
+		photoURL, err := h.signURL(ctx, entry.PhotoKey)
+		if err != nil {
+			return err
+		}
+		thumbnailURL, err := h.signURL(ctx, entry.ThumbnailKey)
+		if err != nil {
+			return err
+		}
+		entry.ProgressPhotoURL, entry.ProgressThumbnailURL = photoURL, thumbnailURL
+	}
+	return nil
+}
+
+// signAttachments fills in the signed URLs of attachments
+func (h *Handler) signAttachments(ctx context.Context, attachments []models.Attachment) error {
+	for i := range attachments {
+		a := &attachments[i]
+		var err error
+		if a.URL, err = h.signURL(ctx, a.BlobKey); err != nil {
+			return err
+		}
+		if a.ThumbnailURL, err = h.signURL(ctx, a.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTo sorts signed attachments onto the entry and task entries they
+// belong to, keeping their order
+func attachTo(entry *models.DailyEntry, taskEntries []models.TaskEntry, attachments []models.Attachment) {
+	byTaskEntry := map[uuid.UUID][]models.Attachment{}
+	entry.Attachments = []models.Attachment{}
+	for _, a := range attachments {
+		if a.TaskEntryID != nil {
+			byTaskEntry[*a.TaskEntryID] = append(byTaskEntry[*a.TaskEntryID], a)
+		} else {
+			entry.Attachments = append(entry.Attachments, a)
+		}
+	}
+	for i := range taskEntries {
+		taskEntries[i].Attachments = byTaskEntry[taskEntries[i].ID]
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/blob"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// pngImage returns a small PNG image
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := range 64 {
+		for y := range 48 {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 5), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// upload posts a multipart form with a PNG in field, along with the given
+// form fields as name and value pairs, for day of challenge
+func upload(t *testing.T, handler http.HandlerFunc, principal *auth.Principal, challenge *models.Challenge, day int, field string, fields ...string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if err := form.WriteField(fields[i], fields[i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file, err := form.CreateFormFile(field, "photo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Write(pngImage(t))
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return serve(handler, principal, http.MethodPost, &body, form.FormDataContentType(),
+		"challengeId", challenge.ID.String(), "day", strconv.Itoa(day))
+}
+
+// blobCount counts the files kept under dir
+func blobCount(t *testing.T, dir string) int {
+	t.Helper()
+
+	n := 0
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestUploadAttachment(t *testing.T) {
+	principal := newPrincipal()
+	today := dates.Today("UTC")
+
+	setup := func(t *testing.T, start int) (*memStore, *Handler, *models.Challenge, string) {
+		dir := t.TempDir()
+		blobs, err := blob.NewLocal(dir, "", []byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := newMemStore()
+		return m, New(m, blobs), m.addChallenge(principal.UserID, today.AddDate(0, 0, start)), dir
+	}
+
+	t.Run("creates today's entry", func(t *testing.T) {
+		m, h, challenge, dir := setup(t, 0)
+
+		var attachment models.Attachment
+		w := upload(t, h.CreateAttachment, principal, challenge, 1, "file", "caption", "Day one")
+		decode(t, w, http.StatusCreated, &attachment)
+
+		entry, err := m.GetEntry(context.Background(), challenge.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Backfilled || entry.EditedAt != nil {
+			t.Errorf("entry = %+v, want a fresh entry for today", entry)
+		}
+		if attachment.DailyEntryID == nil || *attachment.DailyEntryID != entry.ID {
+			t.Errorf("attachment is on %v, want entry %s", attachment.DailyEntryID, entry.ID)
+		}
+		if attachment.Kind != kindPhoto || attachment.Caption != "Day one" || attachment.URL == "" || attachment.ThumbnailURL == "" {
+			t.Errorf("attachment = %+v, want a signed photo with its caption", attachment)
+		}
+		if n := blobCount(t, dir); n != 2 {
+			t.Errorf("%d blobs stored, want the photo and its thumbnail", n)
+		}
+	})
+
+	t.Run("progress photo", func(t *testing.T) {
+		_, h, challenge, _ := setup(t, 0)
+
+		var attachment models.Attachment
+		w := upload(t, h.UploadEntryPhoto, principal, challenge, 1, "photo", "kind", kindScreenshot)
+		decode(t, w, http.StatusCreated, &attachment)
+		if attachment.Kind != kindProgressPhoto {
+			t.Errorf("kind = %s, want %s", attachment.Kind, kindProgressPhoto)
+		}
+	})
+
+	t.Run("yesterday within the grace window is backfilled", func(t *testing.T) {
+		m, h, challenge, _ := setup(t, -1)
+
+		decode(t, upload(t, h.CreateAttachment, principal, challenge, 1, "file"), http.StatusCreated, nil)
+
+		entry, err := m.GetEntry(context.Background(), challenge.ID, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !entry.Backfilled || entry.EditedAt == nil || !entry.Date.Equal(challenge.StartDate) {
+			t.Errorf("entry = %+v, want a backfilled entry on the first day", entry)
+		}
+	})
+
+	t.Run("task entry", func(t *testing.T) {
+		m, h, challenge, _ := setup(t, 0)
+		task := m.addTask(m.addSection(challenge, "Morning"), "Progress photo")
+		entry := &models.DailyEntry{ChallengeID: challenge.ID, DayNumber: 1, Date: today}
+		m.CreateEntry(context.Background(), entry)
+		te := models.TaskEntry{ID: uuid.New(), DailyEntryID: entry.ID, TaskID: task.ID}
+		m.taskEntries = append(m.taskEntries, te)
+
+		var attachment models.Attachment
+		w := upload(t, h.CreateAttachment, principal, challenge, 1, "file", "task_id", task.ID.String())
+		decode(t, w, http.StatusCreated, &attachment)
+		if attachment.TaskEntryID == nil || *attachment.TaskEntryID != te.ID || attachment.DailyEntryID != nil {
+			t.Errorf("attachment is on %v and %v, want only task entry %s", attachment.DailyEntryID, attachment.TaskEntryID, te.ID)
+		}
+	})
+
+	t.Run("task without an entry removes the stored photo", func(t *testing.T) {
+		m, h, challenge, dir := setup(t, 0)
+		task := m.addTask(m.addSection(challenge, "Morning"), "Progress photo")
+
+		w := upload(t, h.CreateAttachment, principal, challenge, 1, "file", "task_id", task.ID.String())
+		decode(t, w, http.StatusNotFound, nil)
+		if n := blobCount(t, dir); n != 0 {
+			t.Errorf("%d blobs left behind, want none", n)
+		}
+	})
+
+	rejected := []struct {
+		name   string
+		start  int
+		day    int
+		fields []string
+		status int
+	}{
+		{"day not started", 0, 2, nil, http.StatusBadRequest},
+		{"day outside the challenge", 0, 76, nil, http.StatusBadRequest},
+		{"locked day", -5, 1, nil, http.StatusForbidden},
+		{"unknown kind", 0, 1, []string{"kind", "video"}, http.StatusUnprocessableEntity},
+		{"malformed task ID", 0, 1, []string{"task_id", "workout"}, http.StatusUnprocessableEntity},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			m, h, challenge, dir := setup(t, tt.start)
+
+			decode(t, upload(t, h.CreateAttachment, principal, challenge, tt.day, "file", tt.fields...), tt.status, nil)
+			if len(m.entries) != 0 || len(m.attachments) != 0 || blobCount(t, dir) != 0 {
+				t.Error("rejected upload left an entry, attachment or blob behind")
+			}
+		})
+	}
+
+	t.Run("challenge of another user", func(t *testing.T) {
+		_, h, challenge, _ := setup(t, 0)
+
+		decode(t, upload(t, h.CreateAttachment, newPrincipal(), challenge, 1, "file"), http.StatusNotFound, nil)
+	})
+}
@@ -1,120 +1,83 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/hari4698/hardinfinity/internal/auth"
-	"github.com/hari4698/hardinfinity/internal/db"
+	"github.com/hari4698/hardinfinity/internal/dates"
 	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/rules"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/tasktypes"
 	"github.com/hari4698/hardinfinity/internal/utils"
 )
 
+const (
+	// defaultDurationDays is the length of the classic 75 Hard challenge
+	defaultDurationDays = 75
+	// maxDurationDays bounds how long a single challenge can run
+	maxDurationDays = 1000
+)
+
 // GetChallenges retrieves all challenges for the authenticated user
-func GetChallenges(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
-		return
-	}
-
-	rows, err := db.DB.Query(r.Context(), `
-		SELECT id, name, description, start_date, end_date, current_day, status, created_at, updated_at
-				FROM challenges
-				WHERE user_id = $1
-				ORDER BY created_at DESC
-		`, userID)
+	challenges, err := h.store.ListChallenges(r.Context(), principal.UserID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve challenges")
 		return
 	}
-
-	defer rows.Close()
-
-	challenges := []models.Challenge{}
-	for rows.Next() {
-		var c models.Challenge
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.StartDate, &c.EndDate, &c.CurrentDay, &c.Status, &c.CreatedAt, &c.UpdatedAt); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to scan challenge data")
-			return
-		}
-
-		c.UserID = userID
-		challenges = append(challenges, c)
+	for i := range challenges {
+		setLocalDate(&challenges[i])
 	}
 
 	utils.Success(w, http.StatusOK, challenges)
 }
 
-func GetChallenge(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
-		return
-	}
-
-	challengeID := chi.URLParam(r, "id")
-	if challengeID == "" {
-		utils.Error(w, http.StatusBadGateway, "Challenge ID is required")
-		return
-	}
-
-	challengeUUID, err := uuid.Parse(challengeID)
+	challengeUUID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID format")
 		return
 	}
 
-	var challenge models.Challenge
-	err = db.DB.QueryRow(r.Context(), `
-		SELECT id, name, description, start_date, end_date, current_day, status, created_at, updated_at
-				FROM challenges
-				WHERE id = $1 AND user_id = $2
-		`, challengeUUID, userID).Scan(&challenge.ID, &challenge.Name, &challenge.Description, &challenge.StartDate, &challenge.EndDate, &challenge.CurrentDay, &challenge.Status, &challenge.CreatedAt, &challenge.UpdatedAt)
-
+	challenge, err := h.store.GetChallenge(r.Context(), principal.UserID, challengeUUID)
 	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Chanllenge not found")
+		utils.Error(w, http.StatusNotFound, "Challenge not found")
 		return
 	}
+	setLocalDate(challenge)
 
-	challenge.UserID = userID
 	utils.Success(w, http.StatusOK, challenge)
 }
 
 // CreateChallenge creates a new challenge
-func CreateChallenge(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Query to get user ID from clerk_id
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
-		return
-	}
-
 	var challenge models.Challenge
 	if err := json.NewDecoder(r.Body).Decode(&challenge); err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid request body")
@@ -122,385 +85,344 @@ func CreateChallenge(w http.ResponseWriter, r *http.Request) {
 	}
 
 	challenge.ID = uuid.New()
-	challenge.UserID = userID
+	challenge.UserID = principal.UserID
 	challenge.Status = "active"
 	challenge.CurrentDay = 1
-	challenge.CreatedAt = time.Now()
-	challenge.UpdatedAt = time.Now()
 
-	_, err = db.DB.Exec(r.Context(), `
-			INSERT INTO challenges (id, user_id, name, description, start_date, end_date, current_day, status, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		`, challenge.ID, challenge.UserID, challenge.Name, challenge.Description, challenge.StartDate, challenge.EndDate, challenge.CurrentDay, challenge.Status, challenge.CreatedAt, challenge.UpdatedAt)
+	if err := normalizeChallengeLength(&challenge); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	if err != nil {
+	if challenge.Timezone != "" && !dates.Valid(challenge.Timezone) {
+		utils.Error(w, http.StatusBadRequest, "Timezone must be an IANA timezone")
+		return
+	}
+
+	if challenge.GraceHours != nil && (*challenge.GraceHours < 0 || *challenge.GraceHours > rules.MaxGraceHours) {
+		utils.Error(w, http.StatusBadRequest, fmt.Sprintf("Grace hours must be between 0 and %d", rules.MaxGraceHours))
+		return
+	}
+
+	if err := h.store.CreateChallenge(r.Context(), &challenge); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to create challenge")
 		return
 	}
+	setLocalDate(&challenge)
 
 	utils.Success(w, http.StatusCreated, challenge)
 }
 
-// UpdateChallenge updates an existing challenge
-func UpdateChallenge(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+type UpdateChallengeRequest struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	EndDate      time.Time `json:"end_date"`
+	DurationDays int       `json:"duration_days"`
+	Timezone     string    `json:"timezone"`
+	GraceHours   *int      `json:"grace_hours"`
+}
+
+// UpdateChallenge updates the settings of an existing challenge. Its start
+// date, current day and status are left alone: they only change through the
+// reset endpoint and as days are logged and rolled over. Without an end date
+// or duration the challenge keeps its length.
+func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Query to get user ID from clerk_id
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	challengeID := chi.URLParam(r, "id")
-	if challengeID == "" {
-		utils.Error(w, http.StatusBadRequest, "Challenge ID is required")
+	var req UpdateChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	challengeUUID, err := uuid.Parse(challengeID)
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID format")
-		return
+	challenge.Name = req.Name
+	challenge.Description = req.Description
+	challenge.Timezone = req.Timezone
+	challenge.GraceHours = req.GraceHours
+	if !req.EndDate.IsZero() || req.DurationDays != 0 {
+		challenge.EndDate = req.EndDate
+		challenge.DurationDays = req.DurationDays
 	}
 
-	// Check if the challenge exists and belongs to the user
-	var exists bool
-	err = db.DB.QueryRow(r.Context(), "SELECT EXISTS(SELECT 1 FROM challenges WHERE id = $1 AND user_id = $2)", challengeUUID, userID).Scan(&exists)
-	if err != nil || !exists {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
+	if err := normalizeChallengeLength(challenge); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	var challenge models.Challenge
-	if err := json.NewDecoder(r.Body).Decode(&challenge); err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+	if challenge.DurationDays < challenge.CurrentDay {
+		utils.Error(w, http.StatusBadRequest, "Duration days must not be less than the current day")
 		return
 	}
 
-	challenge.ID = challengeUUID
-	challenge.UserID = userID
-	challenge.UpdatedAt = time.Now()
+	if challenge.Timezone != "" && !dates.Valid(challenge.Timezone) {
+		utils.Error(w, http.StatusBadRequest, "Timezone must be an IANA timezone")
+		return
+	}
 
-	_, err = db.DB.Exec(r.Context(), `
-		UPDATE challenges 
-		SET name = $1, description = $2, start_date = $3, end_date = $4, current_day = $5, status = $6, updated_at = $7
-		WHERE id = $8 AND user_id = $9
-	`, challenge.Name, challenge.Description, challenge.StartDate, challenge.EndDate, challenge.CurrentDay, challenge.Status, challenge.UpdatedAt, challenge.ID, challenge.UserID)
+	if challenge.GraceHours != nil && (*challenge.GraceHours < 0 || *challenge.GraceHours > rules.MaxGraceHours) {
+		utils.Error(w, http.StatusBadRequest, fmt.Sprintf("Grace hours must be between 0 and %d", rules.MaxGraceHours))
+		return
+	}
 
-	if err != nil {
+	if err := h.store.UpdateChallenge(r.Context(), challenge); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Challenge not found")
+			return
+		}
 		utils.Error(w, http.StatusInternalServerError, "Failed to update challenge")
 		return
 	}
+	setLocalDate(challenge)
 
 	utils.Success(w, http.StatusOK, challenge)
 }
 
 // DeleteChallenge deletes a challenge by ID
-func DeleteChallenge(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Query to get user ID from clerk_id
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
-		return
-	}
-
-	challengeID := chi.URLParam(r, "id")
-	if challengeID == "" {
-		utils.Error(w, http.StatusBadRequest, "Challenge ID is required")
-		return
-	}
-
-	challengeUUID, err := uuid.Parse(challengeID)
+	challengeUUID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID format")
 		return
 	}
 
-	// Start a transaction for cascading deletes
-	tx, err := db.DB.Begin(r.Context())
+	// Attachments go with the challenge, so their files are removed too
+	var attachments []models.Attachment
+	err = h.store.WithTx(r.Context(), func(tx store.Store) error {
+		var err error
+		if attachments, err = tx.ListChallengeAttachments(r.Context(), challengeUUID); err != nil {
+			return err
+		}
+		return tx.DeleteChallenge(r.Context(), principal.UserID, challengeUUID)
+	})
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Challenge not found")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to delete challenge")
 		return
 	}
-	defer tx.Rollback(context.Background())
+	h.deleteAttachmentBlobs(r.Context(), attachments)
 
-	// Delete all related records (this would be better handled with SQL CASCADE)
-	// First, get all sections
-	rows, err := tx.Query(r.Context(), "SELECT id FROM sections WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to query sections")
-		return
-	}
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Challenge deleted successfully"})
+}
 
-	var sectionIDs []uuid.UUID
-	for rows.Next() {
-		var sectionID uuid.UUID
-		if err := rows.Scan(&sectionID); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to scan section ID")
-			return
-		}
-		sectionIDs = append(sectionIDs, sectionID)
-	}
-	rows.Close()
+// ResetChallengeRequest is the optional body of a reset
+type ResetChallengeRequest struct {
+	Reason    string     `json:"reason"`
+	StartDate *time.Time `json:"start_date"`
+}
 
-	// Delete tasks for each section
-	for _, sectionID := range sectionIDs {
-		_, err = tx.Exec(r.Context(), "DELETE FROM tasks WHERE section_id = $1", sectionID)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to delete tasks")
-			return
-		}
+// ResetChallenge ends the current attempt and restarts the challenge at day 1.
+// Entries of the ended attempt stay available through the attempt history.
+func (h *Handler) ResetChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
-	// Get all daily entries
-	rows, err = tx.Query(r.Context(), "SELECT id FROM daily_entries WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to query daily entries")
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	var entryIDs []uuid.UUID
-	for rows.Next() {
-		var entryID uuid.UUID
-		if err := rows.Scan(&entryID); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to scan entry ID")
-			return
-		}
-		entryIDs = append(entryIDs, entryID)
+	var req ResetChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "manual reset"
+	}
+	startDate := dates.Today(challenge.EffectiveTimezone)
+	if req.StartDate != nil {
+		startDate = *req.StartDate
 	}
-	rows.Close()
 
-	// Delete task entries for each daily entry
-	for _, entryID := range entryIDs {
-		_, err = tx.Exec(r.Context(), "DELETE FROM task_entries WHERE daily_entry_id = $1", entryID)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to delete task entries")
+	if err := h.store.ResetChallenge(r.Context(), principal.UserID, challenge.ID, req.Reason, startDate); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Challenge not found")
 			return
 		}
-	}
-
-	// Delete daily entries
-	_, err = tx.Exec(r.Context(), "DELETE FROM daily_entries WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete daily entries")
+		utils.Error(w, http.StatusInternalServerError, "Failed to reset challenge")
 		return
 	}
 
-	// Delete measurements
-	_, err = tx.Exec(r.Context(), "DELETE FROM measurements WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete measurements")
-		return
-	}
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Challenge reset successfully"})
+}
 
-	// Delete sections
-	_, err = tx.Exec(r.Context(), "DELETE FROM sections WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete sections")
+// GetChallengeAttempts lists every attempt of a challenge with its stats
+func (h *Handler) GetChallengeAttempts(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Finally delete the challenge
-	result, err := tx.Exec(r.Context(), "DELETE FROM challenges WHERE id = $1 AND user_id = $2", challengeUUID, userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete challenge")
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	rowsAffected := result.RowsAffected()
-	if rowsAffected == 0 {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
+	attempts, err := h.store.ListAttempts(r.Context(), challenge.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to fetch attempts")
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
-		return
-	}
+	utils.Success(w, http.StatusOK, attempts)
+}
 
-	utils.Success(w, http.StatusOK, map[string]string{"message": "Challenge deleted successfully"})
+type CloneChallengeRequest struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	StartDate    time.Time `json:"start_date"`
+	DurationDays int       `json:"duration_days"`
+	// CarryMeasurements copies the latest measurement as the new day 1 baseline
+	CarryMeasurements bool `json:"carry_measurements"`
 }
 
-// ResetChallenge resets a challenge to day 1
-func ResetChallenge(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+// CloneChallenge starts a new active challenge with the same sections and
+// tasks as an existing one. Daily entries are never copied. The body is
+// optional; fields left out are taken from the source challenge.
+func (h *Handler) CloneChallenge(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Query to get user ID from clerk_id
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
+	source, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	challengeID := chi.URLParam(r, "id")
-	if challengeID == "" {
-		utils.Error(w, http.StatusBadRequest, "Challenge ID is required")
+	var req CloneChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	challengeUUID, err := uuid.Parse(challengeID)
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID format")
-		return
+	challenge := models.Challenge{
+		ID:           uuid.New(),
+		UserID:       principal.UserID,
+		Name:         req.Name,
+		Description:  req.Description,
+		StartDate:    req.StartDate,
+		DurationDays: req.DurationDays,
+		CurrentDay:   1,
+		Status:       "active",
+		Timezone:     source.Timezone,
+		GraceHours:   source.GraceHours,
 	}
 
-	// Start a transaction
-	tx, err := db.DB.Begin(r.Context())
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
-		return
+	if challenge.Name == "" {
+		challenge.Name = source.Name
 	}
-	defer tx.Rollback(context.Background())
-
-	// Reset challenge to day 1
-	_, err = tx.Exec(r.Context(), `
-		UPDATE challenges 
-		SET current_day = 1, status = 'active', updated_at = $1
-		WHERE id = $2 AND user_id = $3
-	`, time.Now(), challengeUUID, userID)
-	
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to reset challenge")
-		return
+	if challenge.Description == "" {
+		challenge.Description = source.Description
 	}
-
-	// Delete all daily entries and task entries
-	_, err = tx.Exec(r.Context(), `
-		DELETE FROM task_entries 
-		WHERE daily_entry_id IN (
-			SELECT id FROM daily_entries WHERE challenge_id = $1
-		)
-	`, challengeUUID)
-	
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to clear task entries")
-		return
+	if challenge.StartDate.IsZero() {
+		challenge.StartDate = dates.Today(source.EffectiveTimezone)
+	}
+	if challenge.DurationDays == 0 {
+		challenge.DurationDays = source.DurationDays
 	}
 
-	_, err = tx.Exec(r.Context(), "DELETE FROM daily_entries WHERE challenge_id = $1", challengeUUID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to clear daily entries")
+	if err := normalizeChallengeLength(&challenge); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
+	ctx := r.Context()
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		sections, err := challengeStructure(ctx, tx, source.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.CreateChallenge(ctx, &challenge); err != nil {
+			return err
+		}
+
+		if err := createChallengeStructure(ctx, tx, challenge.ID, sections); err != nil {
+			return err
+		}
+
+		if !req.CarryMeasurements {
+			return nil
+		}
+
+		measurements, err := tx.ListMeasurements(ctx, source.ID)
+		if err != nil || len(measurements) == 0 {
+			return err
+		}
+
+		// Measurements are ordered by date, so the last one is the most recent
+		baseline := measurements[len(measurements)-1]
+		baseline.ID = uuid.Nil
+		baseline.ChallengeID = challenge.ID
+		baseline.DayNumber = 1
+		baseline.Date = challenge.StartDate
+		return tx.CreateMeasurement(ctx, &baseline)
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to clone challenge")
 		return
 	}
 
-	utils.Success(w, http.StatusOK, map[string]string{"message": "Challenge reset successfully"})
+	utils.Success(w, http.StatusCreated, challenge)
 }
 
 // GetChallengeProgress retrieves progress statistics for a challenge
-func GetChallengeProgress(w http.ResponseWriter, r *http.Request) {
-	clerkID, ok := auth.GetUserID(r.Context())
+func (h *Handler) GetChallengeProgress(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Query to get user ID from clerk_id
-	var userID uuid.UUID
-	err := db.DB.QueryRow(r.Context(), "SELECT id FROM users WHERE clerk_id = $1", clerkID).Scan(&userID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve user")
-		return
-	}
-
-	challengeID := chi.URLParam(r, "id")
-	if challengeID == "" {
-		utils.Error(w, http.StatusBadRequest, "Challenge ID is required")
-		return
-	}
-
-	challengeUUID, err := uuid.Parse(challengeID)
+	challengeUUID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID format")
 		return
 	}
 
 	// First, check if challenge exists and belongs to user
-	var challenge models.Challenge
-	err = db.DB.QueryRow(r.Context(), `
-		SELECT id, name, current_day, status 
-		FROM challenges 
-		WHERE id = $1 AND user_id = $2
-	`, challengeUUID, userID).Scan(&challenge.ID, &challenge.Name, &challenge.CurrentDay, &challenge.Status)
-	
+	challenge, err := h.store.GetChallenge(r.Context(), principal.UserID, challengeUUID)
 	if err != nil {
 		utils.Error(w, http.StatusNotFound, "Challenge not found")
 		return
 	}
 
-	// Get completed days count
-	var completedDays int
-	err = db.DB.QueryRow(r.Context(), `
-		SELECT COUNT(*) 
-		FROM daily_entries 
-		WHERE challenge_id = $1 AND completed = true
-	`, challengeUUID).Scan(&completedDays)
-	
+	entries, err := h.store.ListEntries(r.Context(), challengeUUID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve progress data")
 		return
 	}
 
-	// Get streak information
-	var currentStreak, longestStreak int
-	rows, err := db.DB.Query(r.Context(), `
-		SELECT day_number, completed, date
-		FROM daily_entries
-		WHERE challenge_id = $1
-		ORDER BY day_number ASC
-	`, challengeUUID)
-	
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve streak data")
-		return
-	}
-	defer rows.Close()
-
-	var entries []struct {
-		DayNumber int       `json:"day_number"`
-		Completed bool      `json:"completed"`
-		Date      time.Time `json:"date"`
-	}
-
-	for rows.Next() {
-		var entry struct {
-			DayNumber int       `json:"day_number"`
-			Completed bool      `json:"completed"`
-			Date      time.Time `json:"date"`
-		}
-		if err := rows.Scan(&entry.DayNumber, &entry.Completed, &entry.Date); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to scan entry data")
-			return
-		}
-		entries = append(entries, entry)
-	}
-
-	// Calculate streaks
+	// Count completed days and calculate streaks
+	var completedDays, currentStreak, longestStreak int
 	streak := 0
 	for i, entry := range entries {
 		if entry.Completed {
+			completedDays++
 			streak++
 			if streak > longestStreak {
 				longestStreak = streak
@@ -517,25 +439,142 @@ func GetChallengeProgress(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	tasks, err := h.store.ListChallengeTasks(r.Context(), challengeUUID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve progress data")
+		return
+	}
+
+	taskEntries, err := h.store.ListAttemptTaskEntries(r.Context(), challengeUUID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve progress data")
+		return
+	}
+
+	// Entries are summarised as their task was on the day, and tasks that
+	// were archived or moved away keep their stats
+	revisions, err := h.store.ListTaskRevisions(r.Context(), challengeUUID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve progress data")
+		return
+	}
+	history := newTaskHistory(revisions)
+	history.attach(taskEntries)
+	tasks = append(tasks, history.former(taskEntries, tasks)...)
+	timeline := schedule.NewTimeline(challenge, revisions)
+
 	// Create progress response
 	progress := struct {
-		TotalDays      int    `json:"total_days"`
-		CurrentDay     int    `json:"current_day"`
-		CompletedDays  int    `json:"completed_days"`
-		CurrentStreak  int    `json:"current_streak"`
-		LongestStreak  int    `json:"longest_streak"`
-		Status         string `json:"status"`
-		CompletionRate float64 `json:"completion_rate"`
+		TotalDays      int               `json:"total_days"`
+		CurrentDay     int               `json:"current_day"`
+		CompletedDays  int               `json:"completed_days"`
+		CurrentStreak  int               `json:"current_streak"`
+		LongestStreak  int               `json:"longest_streak"`
+		Status         string            `json:"status"`
+		CompletionRate float64           `json:"completion_rate"`
+		LocalDate      string            `json:"local_date"`
+		Tasks          []tasktypes.Stats `json:"tasks"`
 	}{
-		TotalDays:      75, // Default for Hard75, could be customized
+		TotalDays:      challenge.DurationDays,
 		CurrentDay:     challenge.CurrentDay,
 		CompletedDays:  completedDays,
 		CurrentStreak:  currentStreak,
 		LongestStreak:  longestStreak,
 		Status:         challenge.Status,
-		CompletionRate: float64(completedDays) / 75.0 * 100, // Calculate completion percentage
+		CompletionRate: float64(completedDays) / float64(challenge.DurationDays) * 100, // Calculate completion percentage
+		LocalDate:      dates.Format(dates.Today(challenge.EffectiveTimezone)),
+		Tasks:          taskProgress(challenge, timeline, tasks, entries, taskEntries),
 	}
 
 	utils.Success(w, http.StatusOK, progress)
 }
 
+// taskProgress summarises each task according to its type, counting only
+// the entries of days the task was due on under the schedule it had then.
+// Due days are counted on the days timeline has the task in effect.
+func taskProgress(challenge *models.Challenge, timeline *schedule.Timeline, tasks []models.Task, entries []models.DailyEntry, taskEntries []models.TaskEntry) []tasktypes.Stats {
+	dayOf := make(map[uuid.UUID]int, len(entries))
+	for _, entry := range entries {
+		dayOf[entry.ID] = entry.DayNumber
+	}
+
+	byTask := make(map[uuid.UUID][]models.TaskEntry, len(tasks))
+	history := make(map[uuid.UUID]schedule.History, len(tasks))
+	for _, te := range taskEntries {
+		byTask[te.TaskID] = append(byTask[te.TaskID], te)
+		if te.Completed {
+			if history[te.TaskID] == nil {
+				history[te.TaskID] = schedule.History{}
+			}
+			history[te.TaskID][dayOf[te.DailyEntryID]] = true
+		}
+	}
+
+	cal := schedule.CalendarOf(challenge)
+	reached := min(challenge.CurrentDay, challenge.DurationDays)
+
+	stats := make([]tasktypes.Stats, 0, len(tasks))
+	for i := range tasks {
+		task := &tasks[i]
+
+		due := make([]models.TaskEntry, 0, len(byTask[task.ID]))
+		for _, te := range byTask[task.ID] {
+			sched := task.Schedule
+			if te.Task != nil {
+				sched = te.Task.Schedule
+			}
+			if schedule.Due(sched, cal, dayOf[te.DailyEntryID], history[task.ID]) {
+				due = append(due, te)
+			}
+		}
+
+		// A task is not due before it was added or once it was archived
+		taskStats := tasktypes.Summarize(task, due)
+		for day := 1; day <= reached; day++ {
+			rev, ok := timeline.Task(task.ID, day)
+			if ok && schedule.Due(rev.Schedule, cal, day, history[task.ID]) {
+				taskStats.DueDays++
+			}
+		}
+		stats = append(stats, taskStats)
+	}
+
+	return stats
+}
+
+// normalizeChallengeLength validates the requested length of a challenge and
+// makes duration_days and end_date agree. An end date takes precedence in
+// deriving the duration; without either the classic 75 days is used.
+func normalizeChallengeLength(c *models.Challenge) error {
+	if c.StartDate.IsZero() {
+		return errors.New("Start date is required")
+	}
+
+	if !c.EndDate.IsZero() {
+		if c.EndDate.Before(c.StartDate) {
+			return errors.New("End date must not be before the start date")
+		}
+
+		derived := dates.Between(c.StartDate, c.EndDate) + 1
+		if c.DurationDays != 0 && c.DurationDays != derived {
+			return errors.New("Duration days does not match the end date")
+		}
+		c.DurationDays = derived
+	}
+
+	if c.DurationDays == 0 {
+		c.DurationDays = defaultDurationDays
+	}
+
+	if c.DurationDays < 1 || c.DurationDays > maxDurationDays {
+		return fmt.Errorf("Duration days must be between 1 and %d", maxDurationDays)
+	}
+
+	c.EndDate = c.StartDate.AddDate(0, 0, c.DurationDays-1)
+	return nil
+}
+
+// setLocalDate fills in today's date in the challenge's timezone
+func setLocalDate(c *models.Challenge) {
+	c.LocalDate = dates.Format(dates.Today(c.EffectiveTimezone))
+}
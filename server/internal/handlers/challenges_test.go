@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+func TestCloneChallenge(t *testing.T) {
+	principal := newPrincipal()
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	weight := 80.5
+	ctx := context.Background()
+
+	setup := func() (*memStore, *models.Challenge) {
+		m := newMemStore()
+		source := m.addChallenge(principal.UserID, start)
+		m.challenges[source.ID].CurrentDay, m.challenges[source.ID].Status = 40, "failed"
+
+		morning := m.addSection(source, "Morning")
+		m.addTask(morning, "Workout")
+		m.addTask(morning, "Read")
+		m.addTask(m.addSection(source, "Evening"), "Journal")
+
+		m.CreateMeasurement(ctx, &models.Measurement{ChallengeID: source.ID, DayNumber: 1, Date: start})
+		m.CreateMeasurement(ctx, &models.Measurement{ChallengeID: source.ID, DayNumber: 30, Date: start.AddDate(0, 0, 29), Weight: &weight})
+		return m, source
+	}
+
+	// structure lists the sections of a challenge in order, each followed by
+	// its tasks
+	structure := func(m *memStore, challengeID uuid.UUID) []string {
+		var names []string
+		sections, _ := m.ListSections(ctx, challengeID)
+		for _, section := range sections {
+			names = append(names, section.Name)
+			tasks, _ := m.ListTasks(ctx, section.ID)
+			for _, task := range tasks {
+				names = append(names, "- "+task.Name)
+			}
+		}
+		return names
+	}
+
+	t.Run("empty body", func(t *testing.T) {
+		m, source := setup()
+
+		var clone models.Challenge
+		w := serveJSON(New(m, nil).CloneChallenge, principal, http.MethodPost, "", "id", source.ID.String())
+		decode(t, w, http.StatusCreated, &clone)
+
+		if clone.ID == source.ID || clone.Name != source.Name || clone.DurationDays != source.DurationDays {
+			t.Errorf("clone = %+v, want a new challenge named and sized like the source", clone)
+		}
+		if clone.Status != "active" || clone.CurrentDay != 1 {
+			t.Errorf("clone is %s on day %d, want active on day 1", clone.Status, clone.CurrentDay)
+		}
+		if today := dates.Today("UTC"); !clone.StartDate.Equal(today) {
+			t.Errorf("clone starts on %s, want today, %s", dates.Format(clone.StartDate), dates.Format(today))
+		}
+
+		if got, want := structure(m, clone.ID), structure(m, source.ID); !slices.Equal(got, want) {
+			t.Errorf("clone has %v, want %v", got, want)
+		}
+
+		if measurements, _ := m.ListMeasurements(ctx, clone.ID); len(measurements) != 0 {
+			t.Errorf("clone has %d measurements, want none", len(measurements))
+		}
+	})
+
+	t.Run("carries the latest measurement", func(t *testing.T) {
+		m, source := setup()
+
+		var clone models.Challenge
+		w := serveJSON(New(m, nil).CloneChallenge, principal, http.MethodPost,
+			`{"name": "Round two", "start_date": "2025-06-02T00:00:00Z", "duration_days": 30, "carry_measurements": true}`,
+			"id", source.ID.String())
+		decode(t, w, http.StatusCreated, &clone)
+
+		if clone.Name != "Round two" || clone.DurationDays != 30 || dates.Format(clone.StartDate) != "2025-06-02" {
+			t.Errorf("clone = %+v, want the requested name, length and start", clone)
+		}
+
+		measurements, _ := m.ListMeasurements(ctx, clone.ID)
+		if len(measurements) != 1 {
+			t.Fatalf("clone has %d measurements, want 1", len(measurements))
+		}
+		baseline := measurements[0]
+		if baseline.DayNumber != 1 || !baseline.Date.Equal(clone.StartDate) || baseline.Weight == nil || *baseline.Weight != weight {
+			t.Errorf("baseline = %+v, want the day 30 weight on day 1", baseline)
+		}
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		m, source := setup()
+
+		w := serveJSON(New(m, nil).CloneChallenge, principal, http.MethodPost, `{"duration_days": 5000}`, "id", source.ID.String())
+		decode(t, w, http.StatusBadRequest, nil)
+	})
+
+	t.Run("challenge of another user", func(t *testing.T) {
+		m, source := setup()
+
+		w := serveJSON(New(m, nil).CloneChallenge, newPrincipal(), http.MethodPost, "", "id", source.ID.String())
+		decode(t, w, http.StatusNotFound, nil)
+	})
+}
@@ -1,83 +1,85 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/hari4698/hardinfinity/internal/db"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
 	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/rules"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/tasktypes"
 	"github.com/hari4698/hardinfinity/internal/utils"
 )
 
-func GetDailyEntries(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
-		return
-	}
+// entryRequest is the body accepted when saving a daily entry. Whether the
+// day is completed is derived from its task entries. Photos are uploaded as
+// attachments instead.
+type entryRequest struct {
+	Notes       string             `json:"notes"`
+	EnergyLevel int                `json:"energy_level"`
+	MoodLevel   int                `json:"mood_level"`
+	TaskEntries []taskEntryRequest `json:"task_entries"`
+}
 
-	ctx := r.Context()
+// outstandingTask is a required task that is not completed yet
+type outstandingTask struct {
+	TaskID uuid.UUID `json:"task_id"`
+	Name   string    `json:"name"`
+}
 
-	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
+// taskEntryRequest is the result of one task within an entryRequest. Value
+// is checked against the task's type before anything is saved, and decides
+// completion when the task has a target.
+type taskEntryRequest struct {
+	TaskID    string `json:"task_id"`
+	Completed bool   `json:"completed"`
+	Value     any    `json:"value"`
+	Notes     string `json:"notes"`
+}
 
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
+func (h *Handler) GetDailyEntries(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	rows, err := db.DB.Query(ctx,
-		`SELECT id, challenge_id, day_number, date, completed, notes,
-			progress_photo_url, energy_level, mood_level, created_at, updated_at
-			FROM daily_entries
-			WHERE challenge_id = $1
-			ORDER BY day_number ASC`,
-		challengeID)
+	// Verify user has access to this challenge
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
 
+	entries, err := h.store.ListEntries(r.Context(), challenge.ID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve daily entries")
 		return
 	}
-	defer rows.Close()
-
-	entries := []models.DailyEntry{}
-	for rows.Next() {
-		var entry models.DailyEntry
-		if err := rows.Scan(
-			&entry.ID,
-			&entry.ChallengeID,
-			&entry.DayNumber,
-			&entry.Date,
-			&entry.Completed,
-			&entry.Notes,
-			&entry.ProgressPhotoURL,
-			&entry.EnergyLevel,
-			&entry.MoodLevel,
-			&entry.CreatedAt,
-			&entry.UpdatedAt,
-		); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Error parsing daily entry data")
+	for i := range entries {
+		if err := h.signPhotos(r.Context(), &entries[i]); err != nil {
+			utils.Error(w, http.StatusInternalServerError, "Failed to sign photo URL")
 			return
 		}
-		entries = append(entries, entry)
 	}
 
 	utils.Success(w, http.StatusOK, entries)
 }
 
 // GetDailyEntry retrieves a specific daily entry by day number
-func GetDailyEntry(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
+func (h *Handler) GetDailyEntry(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -87,273 +89,261 @@ func GetDailyEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-
 	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
-
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
-		return
-	}
-
-	var entry models.DailyEntry
-	err = db.DB.QueryRow(ctx,
-		`SELECT id, challenge_id, day_number, date, completed, notes,
-		progress_photo_url, energy_level, mood_level, created_at, updated_at
-		FROM daily_entries
-		WHERE challenge_id = $1 AND day_number = $2`,
-		challengeID, dayNumber).Scan(
-		&entry.ID,
-		&entry.ChallengeID,
-		&entry.DayNumber,
-		&entry.Date,
-		&entry.Completed,
-		&entry.Notes,
-		&entry.ProgressPhotoURL,
-		&entry.EnergyLevel,
-		&entry.MoodLevel,
-		&entry.CreatedAt,
-		&entry.UpdatedAt,
-	)
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
 
+	entry, err := h.store.GetEntry(r.Context(), challenge.ID, dayNumber)
 	if err != nil {
 		utils.Error(w, http.StatusNotFound, "Entry not found")
 		return
 	}
+	if err := h.signPhotos(r.Context(), entry); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to sign photo URL")
+		return
+	}
 
 	// Get all task entries for this daily entry
-	rows, err := db.DB.Query(ctx,
-		`SELECT id, daily_entry_id, task_id, completed, value, notes, created_at, updated_at
-		FROM task_entries
-		WHERE daily_entry_id = $1`,
-		entry.ID)
+	taskEntries, err := h.store.ListTaskEntries(r.Context(), entry.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task entries")
+		return
+	}
 
+	completions, err := h.store.ListTaskCompletions(r.Context(), challenge.ID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task entries")
 		return
 	}
-	defer rows.Close()
-
-	taskEntries := []models.TaskEntry{}
-	for rows.Next() {
-		var taskEntry models.TaskEntry
-		var valueJSON []byte
-
-		if err := rows.Scan(
-			&taskEntry.ID,
-			&taskEntry.DailyEntryID,
-			&taskEntry.TaskID,
-			&taskEntry.Completed,
-			&valueJSON,
-			&taskEntry.Notes,
-			&taskEntry.CreatedAt,
-			&taskEntry.UpdatedAt,
-		); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Error parsing task entry data")
-			return
-		}
 
-		// Parse the JSON value field
-		if len(valueJSON) > 0 {
-			json.Unmarshal(valueJSON, &taskEntry.Value)
-		}
+	// Show each task entry with the task as it was that day, and judge the
+	// day by the tasks in effect on it
+	revisions, err := h.store.ListTaskRevisions(r.Context(), challenge.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve tasks")
+		return
+	}
+	newTaskHistory(revisions).attach(taskEntries)
+	tasks := schedule.NewTimeline(challenge, revisions).On(dayNumber)
 
-		taskEntries = append(taskEntries, taskEntry)
+	attachments, err := h.store.ListEntryAttachments(r.Context(), entry.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve attachments")
+		return
+	}
+	if err := h.signAttachments(r.Context(), attachments); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to sign photo URL")
+		return
 	}
+	attachTo(entry, taskEntries, attachments)
 
 	// Combine daily entry with task entries
 	result := map[string]any{
 		"entry":       entry,
 		"taskEntries": taskEntries,
+		"outstanding": outstandingTasks(tasks, schedule.CalendarOf(challenge), dayNumber, completions),
+		"locked_at":   rules.LockedAt(challenge, dayNumber),
 	}
 
 	utils.Success(w, http.StatusOK, result)
 }
 
-// CreateOrUpdateTodayEntry creates or updates an entry for today
-func CreateOrUpdateTodayEntry(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
-		return
-	}
-
-	ctx := r.Context()
+// agendaSection is a section with the tasks due on an agenda's day
+type agendaSection struct {
+	models.Section
+	Tasks []agendaTask `json:"tasks"`
+}
 
-	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var challenge models.Challenge
-	err = db.DB.QueryRow(ctx,
-		`SELECT id, user_id, current_day, start_date, status
-		FROM challenges WHERE id = $1`,
-		challengeID).Scan(
-		&challenge.ID,
-		&challenge.UserID,
-		&challenge.CurrentDay,
-		&challenge.StartDate,
-		&challenge.Status,
-	)
+// agendaTask is a due task along with what was recorded for it that day
+type agendaTask struct {
+	models.Task
+	Entry *models.TaskEntry `json:"entry"`
+}
 
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
+// GetDayAgenda lists the tasks due on a day of the current attempt, grouped
+// by section. Sections with nothing due are left out.
+func (h *Handler) GetDayAgenda(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	if challenge.UserID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	if challenge.Status != "active" {
-		utils.Error(w, http.StatusBadRequest, "Can only add entries to active challenges")
+	dayNumber, err := strconv.Atoi(chi.URLParam(r, "day"))
+	if err != nil || dayNumber < 1 || dayNumber > challenge.DurationDays {
+		utils.Error(w, http.StatusBadRequest, "Invalid day number")
 		return
 	}
 
-	// Parse request body
-	var entryData struct {
-		Completed        bool                     `json:"completed"`
-		Notes            string                   `json:"notes"`
-		ProgressPhotoURL string                   `json:"progress_photo_url"`
-		EnergyLevel      int                      `json:"energy_level"`
-		MoodLevel        int                      `json:"mood_level"`
-		TaskEntries      []map[string]any `json:"task_entries"`
-	}
-
-	if err := json.NewDecoder(r.Body).Decode(&entryData); err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+	sections, err := h.store.ListSections(r.Context(), challenge.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve sections")
 		return
 	}
 
-	// Current day for the challenge
-	dayNumber := challenge.CurrentDay
-	today := time.Now()
-
-	// Start a transaction
-	tx, err := db.DB.Begin(ctx)
+	// The day lists its tasks as they were defined on it
+	revisions, err := h.store.ListTaskRevisions(r.Context(), challenge.ID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to begin transaction")
-		return
-	}
-	defer tx.Rollback(ctx)
-
-	// Check if entry exists for today
-	var entryID uuid.UUID
-	var exists bool
-	err = tx.QueryRow(ctx,
-		"SELECT id FROM daily_entries WHERE challenge_id = $1 AND day_number = $2",
-		challengeID, dayNumber).Scan(&entryID)
-
-	if err == nil {
-		// Entry exists, update it
-		exists = true
-		_, err = tx.Exec(ctx,
-			`UPDATE daily_entries
-			SET completed = $1, notes = $2, progress_photo_url = $3,
-			energy_level = $4, mood_level = $5, updated_at = NOW()
-			WHERE id = $6`,
-			entryData.Completed, entryData.Notes, entryData.ProgressPhotoURL,
-			entryData.EnergyLevel, entryData.MoodLevel, entryID)
-	} else {
-		// Create new entry
-		exists = false
-		entryID = uuid.New()
-		_, err = tx.Exec(ctx,
-			`INSERT INTO daily_entries
-			(id, challenge_id, day_number, date, completed, notes,
-			progress_photo_url, energy_level, mood_level, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())`,
-			entryID, challengeID, dayNumber, today, entryData.Completed, entryData.Notes,
-			entryData.ProgressPhotoURL, entryData.EnergyLevel, entryData.MoodLevel)
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve tasks")
+		return
 	}
+	tasks := schedule.NewTimeline(challenge, revisions).On(dayNumber)
 
+	completions, err := h.store.ListTaskCompletions(r.Context(), challenge.ID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to save daily entry")
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task entries")
 		return
 	}
 
-	// Process task entries
-	for _, taskEntryData := range entryData.TaskEntries {
-		taskID, err := uuid.Parse(taskEntryData["task_id"].(string))
+	// The day may not have an entry yet
+	recorded := map[uuid.UUID]*models.TaskEntry{}
+	entry, err := h.store.GetEntry(r.Context(), challenge.ID, dayNumber)
+	switch {
+	case err == nil:
+		taskEntries, err := h.store.ListTaskEntries(r.Context(), entry.ID)
 		if err != nil {
-			utils.Error(w, http.StatusBadRequest, "Invalid task ID in task entries")
+			utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task entries")
 			return
 		}
-
-		completed, _ := taskEntryData["completed"].(bool)
-		notes, _ := taskEntryData["notes"].(string)
-		value := taskEntryData["value"]
-
-		// Convert value to JSON
-		valueJSON, err := json.Marshal(value)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to process task entry value")
-			return
+		for i := range taskEntries {
+			recorded[taskEntries[i].TaskID] = &taskEntries[i]
 		}
+	case !errors.Is(err, store.ErrNotFound):
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve daily entry")
+		return
+	}
 
-		// Check if task entry exists
-		var taskEntryID uuid.UUID
-		err = tx.QueryRow(ctx,
-			"SELECT id FROM task_entries WHERE daily_entry_id = $1 AND task_id = $2",
-			entryID, taskID).Scan(&taskEntryID)
-
-		if err == nil {
-			// Update existing task entry
-			_, err = tx.Exec(ctx,
-				`UPDATE task_entries
-				SET completed = $1, value = $2, notes = $3, updated_at = NOW()
-				WHERE id = $4`,
-				completed, valueJSON, notes, taskEntryID)
-		} else {
-			// Create new task entry
-			taskEntryID = uuid.New()
-			_, err = tx.Exec(ctx,
-				`INSERT INTO task_entries
-				(id, daily_entry_id, task_id, completed, value, notes, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())`,
-				taskEntryID, entryID, taskID, completed, valueJSON, notes)
+	cal := schedule.CalendarOf(challenge)
+	due := map[uuid.UUID][]agendaTask{}
+	for _, task := range tasks {
+		if schedule.Due(task.Schedule, cal, dayNumber, schedule.HistoryOf(completions[task.ID])) {
+			due[task.SectionID] = append(due[task.SectionID], agendaTask{Task: task, Entry: recorded[task.ID]})
 		}
+	}
 
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to save task entry")
-			return
+	agenda := []agendaSection{}
+	for _, section := range sections {
+		if len(due[section.ID]) > 0 {
+			agenda = append(agenda, agendaSection{Section: section, Tasks: due[section.ID]})
 		}
 	}
 
-	// If entry is marked as completed and it's the first time, increment current_day in challenge
-	if entryData.Completed && !exists {
-		_, err = tx.Exec(ctx,
-			"UPDATE challenges SET current_day = current_day + 1, updated_at = NOW() WHERE id = $1",
-			challengeID)
+	setLocalDate(challenge)
+	utils.Success(w, http.StatusOK, map[string]any{
+		"day_number": dayNumber,
+		"date":       dates.Format(cal.Date(dayNumber)),
+		"local_date": challenge.LocalDate,
+		"locked_at":  rules.LockedAt(challenge, dayNumber),
+		"sections":   agenda,
+	})
+}
+
+// CreateOrUpdateTodayEntry creates or updates an entry for today
+func (h *Handler) CreateOrUpdateTodayEntry(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Verify user has access to this challenge
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
+
+	if challenge.Status != "active" {
+		utils.Error(w, http.StatusBadRequest, "Can only add entries to active challenges")
+		return
+	}
+
+	// Parse request body
+	var entryData entryRequest
+	if err := json.NewDecoder(r.Body).Decode(&entryData); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Today's day number and date both come from the calendar, so they
+	// always match even when current_day is out of step with it
+	ctx := r.Context()
+	now := time.Now()
+	today := dates.Of(now, dates.Location(challenge.EffectiveTimezone))
+	dayNumber := schedule.CalendarOf(challenge).Day(today)
+	if dayNumber < 1 {
+		utils.Error(w, http.StatusBadRequest, "Challenge has not started yet")
+		return
+	}
+	if dayNumber > challenge.DurationDays {
+		utils.Error(w, http.StatusBadRequest, "Challenge has already reached its final day")
+		return
+	}
+	date := schedule.CalendarOf(challenge).Date(dayNumber)
+
+	taskEntries, ok := h.validateTaskEntries(w, r, challenge, dayNumber, entryData.TaskEntries)
+	if !ok {
+		return
+	}
 
+	var entry *models.DailyEntry
+	var outstanding []outstandingTask
+	var verdict *rules.Verdict
+	err := h.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		var completed bool
+		entry, outstanding, completed, err = saveEntry(ctx, tx, challenge, dayNumber, date, now, entryData, taskEntries)
 		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to update challenge progress")
-			return
+			return err
+		}
+
+		// A broken rule fails or resets the challenge, which then stays put
+		verdict, err = rules.Enforce(ctx, tx, challenge, now)
+		if err != nil || verdict.Action != rules.ActionNone {
+			return err
 		}
-	}
 
-	// Commit transaction
-	if err := tx.Commit(ctx); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
+		// The rollover moves the challenge on to the next day once the
+		// current one is over. Only the first completion of the final day
+		// finishes the challenge early.
+		if completed && dayNumber == challenge.DurationDays {
+			if err := tx.SetChallengeDay(ctx, challenge.ID, dayNumber); err != nil {
+				return err
+			}
+			return tx.SetChallengeStatus(ctx, challenge.ID, "completed")
+		}
+		return nil
+	})
+
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to save daily entry")
 		return
 	}
 
 	utils.Success(w, http.StatusOK, map[string]any{
-		"entry_id":   entryID,
-		"day_number": dayNumber,
-		"message":    "Daily entry saved successfully",
+		"entry_id":    entry.ID,
+		"day_number":  dayNumber,
+		"local_date":  dates.Format(today),
+		"completed":   entry.Completed,
+		"outstanding": outstanding,
+		"verdict":     verdict,
+		"message":     "Daily entry saved successfully",
 	})
 }
 
-// UpdateDailyEntry updates a specific daily entry by day number
-func UpdateDailyEntry(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
+// UpdateDailyEntry creates or updates the entry of a specific day. Past days
+// can be backfilled or edited until they lock at the end of the challenge's
+// grace window.
+func (h *Handler) UpdateDailyEntry(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
@@ -363,125 +353,291 @@ func UpdateDailyEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
-
 	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
 
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
+	if dayNumber < 1 || dayNumber > challenge.DurationDays {
+		utils.Error(w, http.StatusBadRequest, "Day number is outside the challenge")
 		return
 	}
 
-	// Parse request body
-	var entryData struct {
-		Completed        bool                     `json:"completed"`
-		Notes            string                   `json:"notes"`
-		ProgressPhotoURL string                   `json:"progress_photo_url"`
-		EnergyLevel      int                      `json:"energy_level"`
-		MoodLevel        int                      `json:"mood_level"`
-		TaskEntries      []map[string]any `json:"task_entries"`
+	// Like today's entry, the day is placed by the calendar rather than by
+	// current_day, which only moves on once the rollover has run
+	now := time.Now()
+	today := dates.Of(now, dates.Location(challenge.EffectiveTimezone))
+	cal := schedule.CalendarOf(challenge)
+	if dayNumber > cal.Day(today) {
+		utils.Error(w, http.StatusBadRequest, "Day has not started yet")
+		return
 	}
 
+	if rules.Locked(challenge, dayNumber, now) {
+		utils.Error(w, http.StatusForbidden, fmt.Sprintf("Day %d is locked", dayNumber))
+		return
+	}
+
+	// Parse request body
+	var entryData entryRequest
 	if err := json.NewDecoder(r.Body).Decode(&entryData); err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Check if entry exists
-	var entryID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT id FROM daily_entries WHERE challenge_id = $1 AND day_number = $2",
-		challengeID, dayNumber).Scan(&entryID)
+	ctx := r.Context()
+	date := cal.Date(dayNumber)
 
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Entry not found")
+	taskEntries, ok := h.validateTaskEntries(w, r, challenge, dayNumber, entryData.TaskEntries)
+	if !ok {
 		return
 	}
 
-	// Start a transaction
-	tx, err := db.DB.Begin(ctx)
+	var entry *models.DailyEntry
+	var outstanding []outstandingTask
+	var verdict *rules.Verdict
+	err = h.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		entry, outstanding, _, err = saveEntry(ctx, tx, challenge, dayNumber, date, now, entryData, taskEntries)
+		if err != nil || challenge.Status != "active" {
+			return err
+		}
+
+		// Editing a past day can use up strikes retroactively
+		verdict, err = rules.Enforce(ctx, tx, challenge, now)
+		return err
+	})
+
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to begin transaction")
+		utils.Error(w, http.StatusInternalServerError, "Failed to update daily entry")
 		return
 	}
-	defer tx.Rollback(ctx)
 
-	// Update the daily entry
-	_, err = tx.Exec(ctx,
-		`UPDATE daily_entries
-		SET completed = $1, notes = $2, progress_photo_url = $3,
-		energy_level = $4, mood_level = $5, updated_at = NOW()
-		WHERE id = $6`,
-		entryData.Completed, entryData.Notes, entryData.ProgressPhotoURL,
-		entryData.EnergyLevel, entryData.MoodLevel, entryID)
+	utils.Success(w, http.StatusOK, map[string]any{
+		"entry_id":    entry.ID,
+		"day_number":  dayNumber,
+		"local_date":  dates.Format(today),
+		"completed":   entry.Completed,
+		"outstanding": outstanding,
+		"verdict":     verdict,
+		"message":     "Daily entry updated successfully",
+	})
+}
 
+// validateTaskEntries checks every task entry of a request against the
+// tasks in effect on dayNumber, as they were defined that day, and their
+// types. Photo values must name an attachment uploaded to the challenge. A
+// task entry already recorded on dayNumber keeps the revision it was recorded
+// against, so it is checked against the task as it was then. All problems are
+// reported together with a 422; on success the entries are returned ready to
+// be saved.
+func (h *Handler) validateTaskEntries(w http.ResponseWriter, r *http.Request, challenge *models.Challenge, dayNumber int, reqs []taskEntryRequest) ([]models.TaskEntry, bool) {
+	challengeID := challenge.ID
+	revisions, err := h.store.ListTaskRevisions(r.Context(), challengeID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to update daily entry")
-		return
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve tasks")
+		return nil, false
 	}
 
-	// Process task entries
-	for _, taskEntryData := range entryData.TaskEntries {
-		taskID, err := uuid.Parse(taskEntryData["task_id"].(string))
+	tasks := schedule.NewTimeline(challenge, revisions).On(dayNumber)
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	entry, err := h.store.GetEntry(r.Context(), challengeID, dayNumber)
+	switch {
+	case err == nil:
+		recorded, err := h.store.ListTaskEntries(r.Context(), entry.ID)
 		if err != nil {
-			utils.Error(w, http.StatusBadRequest, "Invalid task ID in task entries")
-			return
+			utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task entries")
+			return nil, false
+		}
+		history := newTaskHistory(revisions)
+		for _, te := range recorded {
+			if rev := history[te.TaskID][te.TaskRevision]; rev != nil && byID[te.TaskID] != nil {
+				byID[te.TaskID] = rev
+			}
 		}
+	case !errors.Is(err, store.ErrNotFound):
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve daily entry")
+		return nil, false
+	}
 
-		completed, _ := taskEntryData["completed"].(bool)
-		notes, _ := taskEntryData["notes"].(string)
-		value := taskEntryData["value"]
+	var fieldErrors []utils.FieldError
+	reject := func(i int, field, message string) {
+		fieldErrors = append(fieldErrors, utils.FieldError{
+			Field:   fmt.Sprintf("task_entries[%d].%s", i, field),
+			Message: message,
+		})
+	}
 
-		// Convert value to JSON
-		valueJSON, err := json.Marshal(value)
+	taskEntries := make([]models.TaskEntry, 0, len(reqs))
+	seen := make(map[uuid.UUID]bool, len(reqs))
+	for i, req := range reqs {
+		if req.TaskID == "" {
+			reject(i, "task_id", "is required")
+			continue
+		}
+		taskID, err := uuid.Parse(req.TaskID)
 		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to process task entry value")
-			return
+			reject(i, "task_id", "must be a UUID")
+			continue
+		}
+		task, ok := byID[taskID]
+		if !ok {
+			reject(i, "task_id", "is not a task of this challenge")
+			continue
 		}
+		if seen[taskID] {
+			reject(i, "task_id", "is listed more than once")
+			continue
+		}
+		seen[taskID] = true
 
-		// Check if task entry exists
-		var taskEntryID uuid.UUID
-		err = tx.QueryRow(ctx,
-			"SELECT id FROM task_entries WHERE daily_entry_id = $1 AND task_id = $2",
-			entryID, taskID).Scan(&taskEntryID)
-
-		if err == nil {
-			// Update existing task entry
-			_, err = tx.Exec(ctx,
-				`UPDATE task_entries
-				SET completed = $1, value = $2, notes = $3, updated_at = NOW()
-				WHERE id = $4`,
-				completed, valueJSON, notes, taskEntryID)
-		} else {
-			// Create new task entry
-			taskEntryID = uuid.New()
-			_, err = tx.Exec(ctx,
-				`INSERT INTO task_entries
-				(id, daily_entry_id, task_id, completed, value, notes, created_at, updated_at)
-				VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())`,
-				taskEntryID, entryID, taskID, completed, valueJSON, notes)
+		if req.Value == nil {
+			req.Value = task.Config.Default
+		}
+		if err := tasktypes.ValidateValue(task, req.Value); err != nil {
+			reject(i, "value", err.Error())
+			continue
+		}
+		if attachmentID, ok := tasktypes.AttachmentID(task, req.Value); ok {
+			attachment, err := h.store.GetAttachment(r.Context(), challenge.UserID, attachmentID)
+			if err != nil && !errors.Is(err, store.ErrNotFound) {
+				utils.Error(w, http.StatusInternalServerError, "Failed to retrieve attachment")
+				return nil, false
+			}
+			if err != nil || attachment.ChallengeID != challenge.ID {
+				reject(i, "value", "must be an attachment of this challenge")
+				continue
+			}
 		}
 
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to save task entry")
-			return
+		// A task with a target is completed by its value alone
+		completed := req.Completed
+		if met, ok := tasktypes.MeetsTarget(task, req.Value); ok {
+			completed = met
 		}
+
+		taskEntries = append(taskEntries, models.TaskEntry{
+			TaskID:       taskID,
+			TaskRevision: task.Revision,
+			Completed:    completed,
+			Value:        req.Value,
+			Notes:        req.Notes,
+		})
 	}
 
-	// Commit transaction
-	if err := tx.Commit(ctx); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
-		return
+	if len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid task entries", fieldErrors)
+		return nil, false
 	}
 
-	utils.Success(w, http.StatusOK, map[string]any{
-		"entry_id":   entryID,
-		"day_number": dayNumber,
-		"message":    "Daily entry updated successfully",
-	})
+	return taskEntries, true
+}
+
+// saveEntry writes the daily entry for dayNumber and its task entries,
+// creating the entry for date when there is none yet. Saving after the day
+// ended, as of now, flags the entry as edited, and as backfilled when nothing
+// had been recorded for the day. The day is completed once every required
+// task in effect and due that day has a completed task entry; the required tasks still
+// missing are returned, along with whether this save completed the day.
+func saveEntry(ctx context.Context, tx store.Store, challenge *models.Challenge, dayNumber int, date, now time.Time, data entryRequest, taskEntries []models.TaskEntry) (*models.DailyEntry, []outstandingTask, bool, error) {
+	entry, err := tx.GetEntry(ctx, challenge.ID, dayNumber)
+	created := false
+	if errors.Is(err, store.ErrNotFound) {
+		created = true
+		entry = newEntry(challenge, dayNumber, date, now)
+	} else if err != nil {
+		return nil, nil, false, err
+	}
+	wasCompleted := entry.Completed
+
+	if !created && dayEnded(challenge, entry.Date, now) {
+		entry.EditedAt = &now
+		if !entry.Backfilled {
+			// The rollover records skipped days without any task entries
+			recorded, err := tx.ListTaskEntries(ctx, entry.ID)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			entry.Backfilled = len(recorded) == 0
+		}
+	}
+
+	entry.Notes = data.Notes
+	entry.EnergyLevel = data.EnergyLevel
+	entry.MoodLevel = data.MoodLevel
+
+	if created {
+		err = tx.CreateEntry(ctx, entry)
+	} else {
+		err = tx.UpdateEntry(ctx, entry)
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	for i := range taskEntries {
+		taskEntries[i].DailyEntryID = entry.ID
+		if err := tx.UpsertTaskEntry(ctx, &taskEntries[i]); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	revisions, err := tx.ListTaskRevisions(ctx, challenge.ID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	tasks := schedule.NewTimeline(challenge, revisions).On(dayNumber)
+	completions, err := tx.ListTaskCompletions(ctx, challenge.ID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	outstanding := outstandingTasks(tasks, schedule.CalendarOf(challenge), dayNumber, completions)
+	if completed := len(outstanding) == 0; completed != entry.Completed {
+		entry.Completed = completed
+		if err := tx.UpdateEntry(ctx, entry); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	return entry, outstanding, entry.Completed && !wasCompleted, nil
+}
+
+// newEntry returns a daily entry for dayNumber on date that is yet to be
+// created. An entry first created after its day ended, as of now, is flagged
+// as edited and backfilled.
+func newEntry(challenge *models.Challenge, dayNumber int, date, now time.Time) *models.DailyEntry {
+	entry := &models.DailyEntry{ChallengeID: challenge.ID, DayNumber: dayNumber, Date: date}
+	if dayEnded(challenge, date, now) {
+		entry.EditedAt = &now
+		entry.Backfilled = true
+	}
+	return entry
+}
+
+// dayEnded reports whether date is over in the challenge's timezone as of now
+func dayEnded(challenge *models.Challenge, date, now time.Time) bool {
+	return now.After(dates.EndOf(date, dates.Location(challenge.EffectiveTimezone)))
+}
+
+// outstandingTasks lists the required tasks that still count as missed on
+// day, given the days each task was completed on. Tasks that are not due
+// that day never hold it up.
+func outstandingTasks(tasks []models.Task, cal schedule.Calendar, day int, completions map[uuid.UUID][]int) []outstandingTask {
+	outstanding := []outstandingTask{}
+	for _, task := range tasks {
+		if !task.Required {
+			continue
+		}
+		if schedule.Missed(task.Schedule, cal, day, schedule.HistoryOf(completions[task.ID])) {
+			outstanding = append(outstanding, outstandingTask{TaskID: task.ID, Name: task.Name})
+		}
+	}
+
+	return outstanding
 }
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"github.com/hari4698/hardinfinity/internal/blob"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// Handler serves the REST API. Every endpoint is a method so that it reaches
+// the database only through the store interfaces, and uploaded files only
+// through the blob store.
+type Handler struct {
+	store store.Store
+	blobs blob.Store
+}
+
+// New creates a Handler backed by s, keeping uploads in blobs
+func New(s store.Store, blobs blob.Store) *Handler {
+	return &Handler{store: s, blobs: blobs}
+}
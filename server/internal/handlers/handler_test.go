@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// memStore keeps challenges and what hangs off them in memory. Only the
+// methods the tested handlers use are implemented; the embedded Store panics
+// on the rest. Lookups that take a user ID honour ownership like the
+// Postgres store does.
+type memStore struct {
+	store.Store
+
+	challenges   map[uuid.UUID]*models.Challenge
+	sections     map[uuid.UUID]*models.Section
+	tasks        map[uuid.UUID]*models.Task
+	measurements map[uuid.UUID]*models.Measurement
+	templates    map[uuid.UUID]*models.Template
+	entries      map[uuid.UUID]*models.DailyEntry
+	taskEntries  []models.TaskEntry
+	attachments  []models.Attachment
+
+	// moveErr is returned by MoveTask and MoveSection when set
+	moveErr error
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		challenges:   map[uuid.UUID]*models.Challenge{},
+		sections:     map[uuid.UUID]*models.Section{},
+		tasks:        map[uuid.UUID]*models.Task{},
+		measurements: map[uuid.UUID]*models.Measurement{},
+		templates:    map[uuid.UUID]*models.Template{},
+		entries:      map[uuid.UUID]*models.DailyEntry{},
+	}
+}
+
+func (m *memStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return fn(m)
+}
+
+func (m *memStore) owns(userID, challengeID uuid.UUID) bool {
+	c, ok := m.challenges[challengeID]
+	return ok && c.UserID == userID
+}
+
+func (m *memStore) GetChallenge(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error) {
+	if !m.owns(userID, challengeID) {
+		return nil, store.ErrNotFound
+	}
+	c := *m.challenges[challengeID]
+	return &c, nil
+}
+
+func (m *memStore) CreateChallenge(ctx context.Context, c *models.Challenge) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	c.EffectiveTimezone = cmp.Or(c.Timezone, "UTC")
+	c.CreatedAt, c.UpdatedAt = time.Now(), time.Now()
+	stored := *c
+	m.challenges[c.ID] = &stored
+	return nil
+}
+
+func (m *memStore) ListSections(ctx context.Context, challengeID uuid.UUID) ([]models.Section, error) {
+	sections := []models.Section{}
+	for _, s := range m.sections {
+		if s.ChallengeID == challengeID {
+			sections = append(sections, *s)
+		}
+	}
+	slices.SortFunc(sections, func(a, b models.Section) int { return a.Order - b.Order })
+	return sections, nil
+}
+
+func (m *memStore) GetSection(ctx context.Context, userID, sectionID uuid.UUID) (*models.Section, error) {
+	s, ok := m.sections[sectionID]
+	if !ok || !m.owns(userID, s.ChallengeID) {
+		return nil, store.ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (m *memStore) CreateSection(ctx context.Context, s *models.Section) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.Order <= 0 {
+		sections, _ := m.ListSections(ctx, s.ChallengeID)
+		s.Order = len(sections) + 1
+	}
+	stored := *s
+	m.sections[s.ID] = &stored
+	return nil
+}
+
+func (m *memStore) MoveSection(ctx context.Context, sectionID, challengeID uuid.UUID, order int) error {
+	if m.moveErr != nil {
+		return m.moveErr
+	}
+	m.sections[sectionID].ChallengeID, m.sections[sectionID].Order = challengeID, order
+	return nil
+}
+
+func (m *memStore) ListTasks(ctx context.Context, sectionID uuid.UUID) ([]models.Task, error) {
+	tasks := []models.Task{}
+	for _, t := range m.tasks {
+		if t.SectionID == sectionID {
+			tasks = append(tasks, *t)
+		}
+	}
+	slices.SortFunc(tasks, func(a, b models.Task) int { return a.Order - b.Order })
+	return tasks, nil
+}
+
+func (m *memStore) GetTask(ctx context.Context, userID, taskID uuid.UUID) (*models.Task, error) {
+	t, ok := m.tasks[taskID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	if s, ok := m.sections[t.SectionID]; !ok || !m.owns(userID, s.ChallengeID) {
+		return nil, store.ErrNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (m *memStore) CreateTask(ctx context.Context, t *models.Task) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	if t.Order <= 0 {
+		tasks, _ := m.ListTasks(ctx, t.SectionID)
+		t.Order = len(tasks) + 1
+	}
+	t.Revision = 1
+	stored := *t
+	m.tasks[t.ID] = &stored
+	return nil
+}
+
+func (m *memStore) MoveTask(ctx context.Context, taskID, sectionID uuid.UUID, order int) error {
+	if m.moveErr != nil {
+		return m.moveErr
+	}
+	m.tasks[taskID].SectionID, m.tasks[taskID].Order = sectionID, order
+	return nil
+}
+
+func (m *memStore) ListMeasurements(ctx context.Context, challengeID uuid.UUID) ([]models.Measurement, error) {
+	measurements := []models.Measurement{}
+	for _, ms := range m.measurements {
+		if ms.ChallengeID == challengeID {
+			measurements = append(measurements, *ms)
+		}
+	}
+	slices.SortFunc(measurements, func(a, b models.Measurement) int { return a.Date.Compare(b.Date) })
+	return measurements, nil
+}
+
+func (m *memStore) CreateMeasurement(ctx context.Context, ms *models.Measurement) error {
+	if ms.ID == uuid.Nil {
+		ms.ID = uuid.New()
+	}
+	stored := *ms
+	m.measurements[ms.ID] = &stored
+	return nil
+}
+
+func (m *memStore) GetMeasurement(ctx context.Context, userID, measurementID uuid.UUID) (*models.Measurement, error) {
+	ms, ok := m.measurements[measurementID]
+	if !ok || !m.owns(userID, ms.ChallengeID) {
+		return nil, store.ErrNotFound
+	}
+	copied := *ms
+	return &copied, nil
+}
+
+func (m *memStore) UpdateMeasurement(ctx context.Context, ms *models.Measurement) error {
+	stored := *ms
+	m.measurements[ms.ID] = &stored
+	return nil
+}
+
+func (m *memStore) GetEntry(ctx context.Context, challengeID uuid.UUID, dayNumber int) (*models.DailyEntry, error) {
+	for _, e := range m.entries {
+		if e.ChallengeID == challengeID && e.DayNumber == dayNumber {
+			copied := *e
+			return &copied, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (m *memStore) CreateEntry(ctx context.Context, e *models.DailyEntry) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	stored := *e
+	m.entries[e.ID] = &stored
+	return nil
+}
+
+func (m *memStore) ListTaskEntries(ctx context.Context, dailyEntryID uuid.UUID) ([]models.TaskEntry, error) {
+	taskEntries := []models.TaskEntry{}
+	for _, te := range m.taskEntries {
+		if te.DailyEntryID == dailyEntryID {
+			taskEntries = append(taskEntries, te)
+		}
+	}
+	return taskEntries, nil
+}
+
+func (m *memStore) CreateAttachment(ctx context.Context, a *models.Attachment) error {
+	a.ID = uuid.New()
+	a.Order = len(m.attachments) + 1
+	m.attachments = append(m.attachments, *a)
+	return nil
+}
+
+func (m *memStore) GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*models.Template, error) {
+	t, ok := m.templates[templateID]
+	if !ok || t.UserID == nil || *t.UserID != userID {
+		return nil, store.ErrNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+// addChallenge stores an active UTC challenge of userID that started on start
+func (m *memStore) addChallenge(userID uuid.UUID, start time.Time) *models.Challenge {
+	c := &models.Challenge{
+		UserID:       userID,
+		Name:         "75 Hard",
+		StartDate:    start,
+		DurationDays: 75,
+		CurrentDay:   1,
+		Status:       "active",
+	}
+	m.CreateChallenge(context.Background(), c)
+	return c
+}
+
+func (m *memStore) addSection(challenge *models.Challenge, name string) *models.Section {
+	s := &models.Section{ChallengeID: challenge.ID, Name: name}
+	m.CreateSection(context.Background(), s)
+	return s
+}
+
+func (m *memStore) addTask(section *models.Section, name string) *models.Task {
+	t := &models.Task{SectionID: section.ID, Name: name, TaskType: "boolean", Required: true}
+	m.CreateTask(context.Background(), t)
+	return t
+}
+
+// newPrincipal returns a signed in user in UTC
+func newPrincipal() *auth.Principal {
+	return &auth.Principal{UserID: uuid.New(), Timezone: "UTC"}
+}
+
+// serve calls handler as principal with body and the chi URL parameters
+// params, given as name and value pairs
+func serve(handler http.HandlerFunc, principal *auth.Principal, method string, body io.Reader, contentType string, params ...string) *httptest.ResponseRecorder {
+	rctx := chi.NewRouteContext()
+	for i := 0; i+1 < len(params); i += 2 {
+		rctx.URLParams.Add(params[i], params[i+1])
+	}
+
+	ctx := context.WithValue(auth.WithPrincipal(context.Background(), principal), chi.RouteCtxKey, rctx)
+	r := httptest.NewRequestWithContext(ctx, method, "/", body)
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// serveJSON calls handler with a JSON body, which is left empty when body is ""
+func serveJSON(handler http.HandlerFunc, principal *auth.Principal, method, body string, params ...string) *httptest.ResponseRecorder {
+	return serve(handler, principal, method, strings.NewReader(body), "application/json", params...)
+}
+
+// decode checks the status of a response and decodes its data into v
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, v any) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if v == nil {
+		return
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		t.Fatal(err)
+	}
+}
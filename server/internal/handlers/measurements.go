@@ -2,108 +2,55 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/hari4698/hardinfinity/internal/db"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
 	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
 	"github.com/hari4698/hardinfinity/internal/utils"
 )
 
 // GetMeasurements retrieves all measurements for a specific challenge
-func GetMeasurements(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
+func (h *Handler) GetMeasurements(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	ctx := r.Context()
-
 	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
-
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
 		return
 	}
 
-	rows, err := db.DB.Query(ctx,
-		`SELECT id, challenge_id, day_number, date, weight, chest, waist,
-		hips, arms, thighs, created_at, updated_at
-		FROM measurements
-		WHERE challenge_id = $1
-		ORDER BY date ASC`,
-		challengeID)
-
+	measurements, err := h.store.ListMeasurements(r.Context(), challenge.ID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve measurements")
 		return
 	}
-	defer rows.Close()
-
-	measurements := []models.Measurement{}
-	for rows.Next() {
-		var measurement models.Measurement
-		if err := rows.Scan(
-			&measurement.ID,
-			&measurement.ChallengeID,
-			&measurement.DayNumber,
-			&measurement.Date,
-			&measurement.Weight,
-			&measurement.Chest,
-			&measurement.Waist,
-			&measurement.Hips,
-			&measurement.Arms,
-			&measurement.Thighs,
-			&measurement.CreatedAt,
-			&measurement.UpdatedAt,
-		); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Error parsing measurement data")
-			return
-		}
-		measurements = append(measurements, measurement)
-	}
 
 	utils.Success(w, http.StatusOK, measurements)
 }
 
 // AddMeasurement adds a new measurement for a challenge
-func AddMeasurement(w http.ResponseWriter, r *http.Request) {
-	challengeID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
+func (h *Handler) AddMeasurement(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	ctx := r.Context()
-
 	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var challenge models.Challenge
-	err = db.DB.QueryRow(ctx,
-		`SELECT id, user_id, current_day, status
-		FROM challenges WHERE id = $1`,
-		challengeID).Scan(
-		&challenge.ID,
-		&challenge.UserID,
-		&challenge.CurrentDay,
-		&challenge.Status,
-	)
-
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
-		return
-	}
-
-	if challenge.UserID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this challenge")
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
 		return
 	}
 
@@ -116,43 +63,24 @@ func AddMeasurement(w http.ResponseWriter, r *http.Request) {
 
 	// Set challenge ID and generate a new UUID
 	measurement.ID = uuid.New()
-	measurement.ChallengeID = challengeID
+	measurement.ChallengeID = challenge.ID
 
 	// If day number is not provided, use the current day of the challenge
 	if measurement.DayNumber <= 0 {
 		measurement.DayNumber = challenge.CurrentDay
 	}
 
-	// If date is not provided, use today's date
+	// If date is not provided, use today's date in the challenge's timezone
 	if measurement.Date.IsZero() {
-		measurement.Date = time.Now()
+		measurement.Date = dates.Today(challenge.EffectiveTimezone)
 	}
 
-	// Current time for created_at and updated_at
-	now := time.Now()
-	measurement.CreatedAt = now
-	measurement.UpdatedAt = now
-
-	// Insert the new measurement
-	_, err = db.DB.Exec(ctx,
-		`INSERT INTO measurements
-		(id, challenge_id, day_number, date, weight, chest, waist,
-		hips, arms, thighs, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
-		measurement.ID,
-		measurement.ChallengeID,
-		measurement.DayNumber,
-		measurement.Date,
-		measurement.Weight,
-		measurement.Chest,
-		measurement.Waist,
-		measurement.Hips,
-		measurement.Arms,
-		measurement.Thighs,
-		measurement.CreatedAt,
-		measurement.UpdatedAt)
+	if fieldErrors := measurementErrors(&measurement); len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid measurement", fieldErrors)
+		return
+	}
 
-	if err != nil {
+	if err := h.store.CreateMeasurement(r.Context(), &measurement); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to save measurement")
 		return
 	}
@@ -160,36 +88,19 @@ func AddMeasurement(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, http.StatusCreated, measurement)
 }
 
-// UpdateMeasurement updates an existing measurement
-func UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
-	measurementID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid measurement ID")
+// UpdateMeasurement replaces an existing measurement. The day number and date
+// are required; values left out of the body are cleared. PatchMeasurement
+// changes only the fields it is given.
+func (h *Handler) UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	ctx := r.Context()
-
-	// First verify the measurement exists and get its challenge ID
-	var challengeID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT challenge_id FROM measurements WHERE id = $1",
-		measurementID).Scan(&challengeID)
-
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Measurement not found")
-		return
-	}
-
-	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
-
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this measurement")
+	// Verify the measurement exists and belongs to the user's challenge
+	measurement, ok := h.ownedMeasurement(w, r, principal)
+	if !ok {
 		return
 	}
 
@@ -197,12 +108,12 @@ func UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
 	var measurementUpdate struct {
 		DayNumber int       `json:"day_number"`
 		Date      time.Time `json:"date"`
-		Weight    float64   `json:"weight"`
-		Chest     float64   `json:"chest"`
-		Waist     float64   `json:"waist"`
-		Hips      float64   `json:"hips"`
-		Arms      float64   `json:"arms"`
-		Thighs    float64   `json:"thighs"`
+		Weight    *float64  `json:"weight"`
+		Chest     *float64  `json:"chest"`
+		Waist     *float64  `json:"waist"`
+		Hips      *float64  `json:"hips"`
+		Arms      *float64  `json:"arms"`
+		Thighs    *float64  `json:"thighs"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&measurementUpdate); err != nil {
@@ -210,102 +121,174 @@ func UpdateMeasurement(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update the measurement
-	_, err = db.DB.Exec(ctx,
-		`UPDATE measurements 
-		SET day_number = $1, date = $2, weight = $3, chest = $4, waist = $5, 
-		hips = $6, arms = $7, thighs = $8, updated_at = NOW()
-		WHERE id = $9`,
-		measurementUpdate.DayNumber,
-		measurementUpdate.Date,
-		measurementUpdate.Weight,
-		measurementUpdate.Chest,
-		measurementUpdate.Waist,
-		measurementUpdate.Hips,
-		measurementUpdate.Arms,
-		measurementUpdate.Thighs,
-		measurementID)
+	measurement.DayNumber = measurementUpdate.DayNumber
+	measurement.Date = measurementUpdate.Date
+	measurement.Weight = measurementUpdate.Weight
+	measurement.Chest = measurementUpdate.Chest
+	measurement.Waist = measurementUpdate.Waist
+	measurement.Hips = measurementUpdate.Hips
+	measurement.Arms = measurementUpdate.Arms
+	measurement.Thighs = measurementUpdate.Thighs
+
+	if fieldErrors := measurementErrors(measurement); len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid measurement", fieldErrors)
+		return
+	}
 
-	if err != nil {
+	if err := h.store.UpdateMeasurement(r.Context(), measurement); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to update measurement")
 		return
 	}
 
-	// Retrieve the updated measurement
-	var updatedMeasurement models.Measurement
-	err = db.DB.QueryRow(ctx,
-		`SELECT id, challenge_id, day_number, date, weight, chest, waist, 
-		hips, arms, thighs, created_at, updated_at
-		FROM measurements 
-		WHERE id = $1`,
-		measurementID).Scan(
-		&updatedMeasurement.ID,
-		&updatedMeasurement.ChallengeID,
-		&updatedMeasurement.DayNumber,
-		&updatedMeasurement.Date,
-		&updatedMeasurement.Weight,
-		&updatedMeasurement.Chest,
-		&updatedMeasurement.Waist,
-		&updatedMeasurement.Hips,
-		&updatedMeasurement.Arms,
-		&updatedMeasurement.Thighs,
-		&updatedMeasurement.CreatedAt,
-		&updatedMeasurement.UpdatedAt,
-	)
+	utils.Success(w, http.StatusOK, measurement)
+}
 
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve updated measurement")
+// PatchMeasurement updates the fields of a measurement given in the body and
+// leaves the rest alone. A value set to null is cleared, marking it as not
+// measured.
+func (h *Handler) PatchMeasurement(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	utils.Success(w, http.StatusOK, updatedMeasurement)
-}
-
-// DeleteMeasurement deletes a measurement
-func DeleteMeasurement(w http.ResponseWriter, r *http.Request) {
-	measurementID, err := uuid.Parse(chi.URLParam(r, "id"))
-	if err != nil {
-		utils.Error(w, http.StatusBadRequest, "Invalid measurement ID")
+	// Verify the measurement exists and belongs to the user's challenge
+	measurement, ok := h.ownedMeasurement(w, r, principal)
+	if !ok {
 		return
 	}
 
-	ctx := r.Context()
+	// Fields are kept raw so that null can be told apart from a missing field
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
 
-	// First verify the measurement exists and get its challenge ID
-	var challengeID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT challenge_id FROM measurements WHERE id = $1",
-		measurementID).Scan(&challengeID)
+	values := measurementValues(measurement)
+	var fieldErrors []utils.FieldError
+	for _, name := range slices.Sorted(maps.Keys(patch)) {
+		raw := patch[name]
+		null := string(raw) == "null"
+
+		switch value, ok := values[name]; {
+		case name == "day_number":
+			var day int
+			if null || json.Unmarshal(raw, &day) != nil || day < 1 {
+				fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Message: "must be a day number"})
+				continue
+			}
+			measurement.DayNumber = day
+		case name == "date":
+			var date time.Time
+			if null || json.Unmarshal(raw, &date) != nil {
+				fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Message: "must be a date"})
+				continue
+			}
+			measurement.Date = date
+		case !ok:
+			fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Message: "is not a measurement field"})
+		case null:
+			*value = nil
+		default:
+			var v float64
+			if json.Unmarshal(raw, &v) != nil || v < 0 {
+				fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Message: "must be a non-negative number or null"})
+				continue
+			}
+			*value = &v
+		}
+	}
+	if len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid measurement", fieldErrors)
+		return
+	}
 
-	if err != nil {
-		utils.Error(w, http.StatusNotFound, "Measurement not found")
+	if err := h.store.UpdateMeasurement(r.Context(), measurement); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update measurement")
 		return
 	}
 
-	// Verify user has access to this challenge
-	userID := r.Context().Value("userID").(uuid.UUID)
-	var ownerID uuid.UUID
-	err = db.DB.QueryRow(ctx,
-		"SELECT user_id FROM challenges WHERE id = $1",
-		challengeID).Scan(&ownerID)
-
-	if err != nil || ownerID != userID {
-		utils.Error(w, http.StatusForbidden, "You don't have access to this measurement")
+	utils.Success(w, http.StatusOK, measurement)
+}
+
+// measurementValues maps the JSON names of a measurement's nullable values to
+// its fields
+func measurementValues(m *models.Measurement) map[string]**float64 {
+	return map[string]**float64{
+		"weight": &m.Weight,
+		"chest":  &m.Chest,
+		"waist":  &m.Waist,
+		"hips":   &m.Hips,
+		"arms":   &m.Arms,
+		"thighs": &m.Thighs,
+	}
+}
+
+// measurementErrors checks that a measurement has a day number and date and
+// that none of its values are negative
+func measurementErrors(m *models.Measurement) []utils.FieldError {
+	var fieldErrors []utils.FieldError
+	if m.DayNumber < 1 {
+		fieldErrors = append(fieldErrors, utils.FieldError{Field: "day_number", Message: "must be a day number"})
+	}
+	if m.Date.IsZero() {
+		fieldErrors = append(fieldErrors, utils.FieldError{Field: "date", Message: "must be a date"})
+	}
+
+	values := measurementValues(m)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if v := *values[name]; v != nil && *v < 0 {
+			fieldErrors = append(fieldErrors, utils.FieldError{Field: name, Message: "must be a non-negative number or null"})
+		}
+	}
+	return fieldErrors
+}
+
+// DeleteMeasurement deletes a measurement
+func (h *Handler) DeleteMeasurement(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Delete the measurement
-	_, err = db.DB.Exec(ctx,
-		"DELETE FROM measurements WHERE id = $1",
-		measurementID)
+	// Verify the measurement exists and belongs to the user's challenge
+	measurement, ok := h.ownedMeasurement(w, r, principal)
+	if !ok {
+		return
+	}
 
-	if err != nil {
+	if err := h.store.DeleteMeasurement(r.Context(), measurement.ID); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to delete measurement")
 		return
 	}
 
 	utils.Success(w, http.StatusOK, map[string]any{
 		"message": "Measurement deleted successfully",
-		"id":      measurementID,
+		"id":      measurement.ID,
 	})
-}
\ No newline at end of file
+}
+
+// ownedMeasurement loads the measurement named by the "id" URL parameter and
+// checks that its challenge belongs to the principal
+func (h *Handler) ownedMeasurement(w http.ResponseWriter, r *http.Request, principal *auth.Principal) (*models.Measurement, bool) {
+	measurementID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid measurement ID")
+		return nil, false
+	}
+
+	measurement, err := h.store.GetMeasurement(r.Context(), principal.UserID, measurementID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Measurement not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve measurement")
+		return nil, false
+	}
+
+	return measurement, true
+}
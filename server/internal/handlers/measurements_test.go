@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// newMeasurement stores a measurement on day 10 with a weight and waist
+func newMeasurement(m *memStore, challenge *models.Challenge) *models.Measurement {
+	weight, waist := 82.0, 90.0
+	ms := &models.Measurement{
+		ChallengeID: challenge.ID,
+		DayNumber:   10,
+		Date:        challenge.StartDate.AddDate(0, 0, 9),
+		Weight:      &weight,
+		Waist:       &waist,
+	}
+	m.CreateMeasurement(context.Background(), ms)
+	return ms
+}
+
+// value formats a nullable measurement value
+func value(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func TestPatchMeasurement(t *testing.T) {
+	principal := newPrincipal()
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		check  func(t *testing.T, got *models.Measurement)
+	}{
+		{
+			name:   "sets a value and leaves the rest",
+			body:   `{"chest": 101.5}`,
+			status: http.StatusOK,
+			check: func(t *testing.T, got *models.Measurement) {
+				if value(got.Chest) != 101.5 || value(got.Weight) != 82.0 || value(got.Waist) != 90.0 || got.DayNumber != 10 {
+					t.Errorf("measurement = %+v, want chest set and everything else kept", got)
+				}
+			},
+		},
+		{
+			name:   "null clears a value",
+			body:   `{"weight": null}`,
+			status: http.StatusOK,
+			check: func(t *testing.T, got *models.Measurement) {
+				if got.Weight != nil || value(got.Waist) != 90.0 {
+					t.Errorf("measurement = %+v, want weight cleared and waist kept", got)
+				}
+			},
+		},
+		{
+			name:   "moves the day",
+			body:   `{"day_number": 12, "date": "2025-03-14T00:00:00Z"}`,
+			status: http.StatusOK,
+			check: func(t *testing.T, got *models.Measurement) {
+				if got.DayNumber != 12 || !got.Date.Equal(time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)) {
+					t.Errorf("measurement on day %d, %s, want day 12 on 2025-03-14", got.DayNumber, got.Date)
+				}
+			},
+		},
+		{name: "negative value", body: `{"hips": -1}`, status: http.StatusUnprocessableEntity},
+		{name: "unknown field", body: `{"height": 180}`, status: http.StatusUnprocessableEntity},
+		{name: "null day number", body: `{"day_number": null}`, status: http.StatusUnprocessableEntity},
+		{name: "malformed body", body: `[1]`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMemStore()
+			ms := newMeasurement(m, m.addChallenge(principal.UserID, start))
+			before := *m.measurements[ms.ID]
+
+			w := serveJSON(New(m, nil).PatchMeasurement, principal, http.MethodPatch, tt.body, "id", ms.ID.String())
+			decode(t, w, tt.status, nil)
+
+			got := m.measurements[ms.ID]
+			if tt.check == nil {
+				if *got != before {
+					t.Errorf("rejected patch changed the measurement to %+v", got)
+				}
+				return
+			}
+			tt.check(t, got)
+		})
+	}
+
+	t.Run("measurement of another user", func(t *testing.T) {
+		m := newMemStore()
+		ms := newMeasurement(m, m.addChallenge(principal.UserID, start))
+
+		w := serveJSON(New(m, nil).PatchMeasurement, newPrincipal(), http.MethodPatch, `{"weight": 70}`, "id", ms.ID.String())
+		decode(t, w, http.StatusNotFound, nil)
+	})
+}
+
+func TestUpdateMeasurement(t *testing.T) {
+	principal := newPrincipal()
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	t.Run("replaces every value", func(t *testing.T) {
+		m := newMemStore()
+		ms := newMeasurement(m, m.addChallenge(principal.UserID, start))
+
+		w := serveJSON(New(m, nil).UpdateMeasurement, principal, http.MethodPut,
+			`{"day_number": 11, "date": "2025-03-13T00:00:00Z", "weight": 81}`, "id", ms.ID.String())
+		decode(t, w, http.StatusOK, nil)
+
+		got := m.measurements[ms.ID]
+		if got.DayNumber != 11 || value(got.Weight) != 81.0 || got.Waist != nil {
+			t.Errorf("measurement = %+v, want day 11 with only a weight", got)
+		}
+	})
+
+	for name, body := range map[string]string{
+		"missing day number": `{"date": "2025-03-13T00:00:00Z", "weight": 81}`,
+		"missing date":       `{"day_number": 11, "weight": 81}`,
+		"negative value":     `{"day_number": 11, "date": "2025-03-13T00:00:00Z", "arms": -3}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := newMemStore()
+			ms := newMeasurement(m, m.addChallenge(principal.UserID, start))
+			before := *m.measurements[ms.ID]
+
+			w := serveJSON(New(m, nil).UpdateMeasurement, principal, http.MethodPut, body, "id", ms.ID.String())
+			decode(t, w, http.StatusUnprocessableEntity, nil)
+			if *m.measurements[ms.ID] != before {
+				t.Errorf("rejected update changed the measurement to %+v", m.measurements[ms.ID])
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/imaging"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/utils"
+)
+
+// maxComparePhotos bounds how many photos one comparison can hold
+const maxComparePhotos = 6
+
+// timelinePhoto is one progress photo of the timeline with the measurement
+// in effect on its day
+type timelinePhoto struct {
+	DayNumber   int                 `json:"day_number"`
+	Date        string              `json:"date"`
+	Photo       models.Attachment   `json:"photo"`
+	Measurement *models.Measurement `json:"measurement"`
+}
+
+// GetPhotoTimeline lists every progress photo of the current attempt by day.
+// Each photo comes with the latest measurement taken on or before its date.
+func (h *Handler) GetPhotoTimeline(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
+		return
+	}
+
+	photos, err := h.progressPhotos(r.Context(), challenge)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve photos")
+		return
+	}
+	if err := h.signAttachments(r.Context(), photos); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to sign photo URL")
+		return
+	}
+
+	measurements, err := h.store.ListMeasurements(r.Context(), challenge.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve measurements")
+		return
+	}
+
+	// Both lists are sorted by date
+	timeline := make([]timelinePhoto, len(photos))
+	next := 0
+	var latest *models.Measurement
+	for i, photo := range photos {
+		for next < len(measurements) && !measurements[next].Date.After(photo.Date) {
+			latest = &measurements[next]
+			next++
+		}
+		timeline[i] = timelinePhoto{
+			DayNumber:   photo.DayNumber,
+			Date:        dates.Format(photo.Date),
+			Photo:       photo,
+			Measurement: latest,
+		}
+	}
+
+	utils.Success(w, http.StatusOK, timeline)
+}
+
+// ComparePhotos renders the progress photos of two or more days of the
+// current attempt side by side as one JPEG, each labelled with its day. The
+// days are listed in "days", as in days=1,38,75, or given as "from" and
+// "to". A day with several progress photos is shown by its first one.
+func (h *Handler) ComparePhotos(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	challenge, ok := h.ownedChallenge(w, r, principal, "id")
+	if !ok {
+		return
+	}
+
+	days, ok := compareDays(w, r)
+	if !ok {
+		return
+	}
+
+	photos, err := h.progressPhotos(r.Context(), challenge)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve photos")
+		return
+	}
+	first := map[int]models.Attachment{}
+	for _, photo := range photos {
+		if _, ok := first[photo.DayNumber]; !ok {
+			first[photo.DayNumber] = photo
+		}
+	}
+
+	panels := make([]imaging.Panel, len(days))
+	for i, day := range days {
+		photo, ok := first[day]
+		if !ok {
+			utils.Error(w, http.StatusNotFound, fmt.Sprintf("No progress photo for day %d", day))
+			return
+		}
+
+		img, err := h.loadImage(r.Context(), photo.BlobKey)
+		if err != nil {
+			utils.Error(w, http.StatusInternalServerError, "Failed to load photo")
+			return
+		}
+		panels[i] = imaging.Panel{Image: img, Label: fmt.Sprintf("DAY %d", day)}
+	}
+
+	composite, err := imaging.Encode(imaging.Compose(panels))
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to render comparison")
+		return
+	}
+
+	w.Header().Set("Content-Type", imaging.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(composite)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(composite)
+}
+
+// compareDays reads the days to compare from the query, writing the error
+// response when they are missing or invalid
+func compareDays(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	query := r.URL.Query()
+
+	var fields []string
+	if v := query.Get("days"); v != "" {
+		fields = strings.Split(v, ",")
+	} else {
+		fields = []string{query.Get("from"), query.Get("to")}
+		if fields[0] == "" || fields[1] == "" {
+			utils.Error(w, http.StatusBadRequest, "Either days or both from and to are required")
+			return nil, false
+		}
+	}
+
+	days := make([]int, len(fields))
+	for i, field := range fields {
+		day, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || day < 1 {
+			utils.Error(w, http.StatusBadRequest, fmt.Sprintf("Invalid day number %q", field))
+			return nil, false
+		}
+		days[i] = day
+	}
+
+	if len(days) < 2 || len(days) > maxComparePhotos {
+		utils.Error(w, http.StatusBadRequest, fmt.Sprintf("Compare from 2 to %d days", maxComparePhotos))
+		return nil, false
+	}
+	return days, true
+}
+
+// progressPhotos returns the progress photos of the challenge's current
+// attempt, by date and then order
+func (h *Handler) progressPhotos(ctx context.Context, challenge *models.Challenge) ([]models.Attachment, error) {
+	attempt, err := h.store.CurrentAttempt(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments, err := h.store.ListChallengeAttachments(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	photos := []models.Attachment{}
+	for _, a := range attachments {
+		if a.AttemptID == attempt.ID && a.Kind == kindProgressPhoto {
+			photos = append(photos, a)
+		}
+	}
+	return photos, nil
+}
+
+// loadImage reads and decodes a stored photo
+func (h *Handler) loadImage(ctx context.Context, key string) (*image.RGBA, error) {
+	rc, err := h.blobs.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return imaging.Decode(data)
+}
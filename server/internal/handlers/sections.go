@@ -1,23 +1,23 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/hari4698/hardinfinity/internal/auth"
-	"github.com/hari4698/hardinfinity/internal/db"
 	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
 	"github.com/hari4698/hardinfinity/internal/utils"
 )
 
 type CreateSectionRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
-	Order       string `json:"order"`
+	Order       int    `json:"order"`
 }
 
 type UpdateSectionRequest struct {
@@ -29,76 +29,53 @@ type ReorderSectionRequest struct {
 	Order int `json:"order"`
 }
 
-// GetSections retrieves all sections for a challenge
-func GetSections(w http.ResponseWriter, r *http.Request) {
-	challengeID := chi.URLParam(r, "id")
+// MoveSectionRequest names the challenge a section moves to and its position
+// there. A missing or non-positive order appends the section.
+type MoveSectionRequest struct {
+	ChallengeID string `json:"challenge_id"`
+	Order       int    `json:"order"`
+}
 
-	// Verify that the challenge exists and belongs to the authenticated user
-	userID, ok := auth.GetUserID(r.Context())
+// OrderRequest lists the IDs of every section of a challenge, or every task
+// of a section, in their new order
+type OrderRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// GetSections retrieves all sections for a challenge
+func (h *Handler) GetSections(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Check challenge ownership
-	if err := validateChallengeOwnership(challengeID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
+	// Verify that the challenge exists and belongs to the authenticated user
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
 		return
 	}
 
-	// Query the database for sections
-	rows, err := db.DB.Query(r.Context(), `
-			SELECT id, challenge_id, name, description, "order", created_at, updated_at
-			FROM sections
-			WHERE challenge_id = $1
-			ORDER BY "order" ASC
-		`, challengeID)
+	sections, err := h.store.ListSections(r.Context(), challenge.ID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve sections")
 		return
 	}
-	defer rows.Close()
-
-	sections := []models.Section{}
-	for rows.Next() {
-		var section models.Section
-		if err := rows.Scan(
-			&section.ID,
-			&section.ChallengeID,
-			&section.Name,
-			&section.Description,
-			&section.Order,
-			&section.CreatedAt,
-			&section.UpdatedAt,
-		); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Error scanning section data")
-			return
-		}
-		sections = append(sections, section)
-	}
-
-	if err := rows.Err(); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Error iterating through sections")
-		return
-	}
 
 	utils.Success(w, http.StatusOK, sections)
 }
 
 // CreateSection creates a new section for a challenge
-func CreateSection(w http.ResponseWriter, r *http.Request) {
-	challengeID := chi.URLParam(r, "id")
-
-	// Verify that the challenge exists and belongs to the authenticated user
-	userID, ok := auth.GetUserID(r.Context())
+func (h *Handler) CreateSection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Check challenge ownership
-	if err := validateChallengeOwnership(challengeID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Challenge not found")
+	// Verify that the challenge exists and belongs to the authenticated user
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
 		return
 	}
 
@@ -115,39 +92,15 @@ func CreateSection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generate a new UUID for the section
-	sectionID := uuid.New().String()
-
-	// If order is not specified, determine the next available order
-	if req.Order == "" || req.Order == "0" {
-		var maxOrder int
-		err := db.DB.QueryRow(r.Context(), `
-			SELECT COALESCE(MAX("order"), 0) FROM sections WHERE challenge_id = $1
-		`, challengeID).Scan(&maxOrder)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to determine section order")
-			return
-		}
-		req.Order = string(maxOrder + 1)
-	}
-
-	// Insert the new section
-	var section models.Section
-	err := db.DB.QueryRow(r.Context(), `
-		INSERT INTO sections (id, challenge_id, name, description, "order", created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
-		RETURNING id, challenge_id, name, description, "order", created_at, updated_at
-	`, sectionID, challengeID, req.Name, req.Description, req.Order).Scan(
-		&section.ID,
-		&section.ChallengeID,
-		&section.Name,
-		&section.Description,
-		&section.Order,
-		&section.CreatedAt,
-		&section.UpdatedAt,
-	)
+	// A non-positive order appends the section after the existing ones
+	section := models.Section{
+		ChallengeID: challenge.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Order:       req.Order,
+	}
 
-	if err != nil {
+	if err := h.store.CreateSection(r.Context(), &section); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to create section")
 		return
 	}
@@ -156,19 +109,16 @@ func CreateSection(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateSection updates an existing section
-func UpdateSection(w http.ResponseWriter, r *http.Request) {
-	sectionID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+func (h *Handler) UpdateSection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	// Check section ownership through challenge
-	if err := validateSectionOwnership(sectionID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Section not found")
+	section, ok := h.ownedSection(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
@@ -185,24 +135,10 @@ func UpdateSection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update the section
-	var section models.Section
-	err := db.DB.QueryRow(r.Context(), `
-		UPDATE sections
-		SET name = $1, description = $2, updated_at = NOW()
-		WHERE id = $3
-		RETURNING id, challenge_id, name, description, "order", created_at, updated_at
-	`, req.Name, req.Description, sectionID).Scan(
-		&section.ID,
-		&section.ChallengeID,
-		&section.Name,
-		&section.Description,
-		&section.Order,
-		&section.CreatedAt,
-		&section.UpdatedAt,
-	)
+	section.Name = req.Name
+	section.Description = req.Description
 
-	if err != nil {
+	if err := h.store.UpdateSection(r.Context(), section); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to update section")
 		return
 	}
@@ -210,67 +146,41 @@ func UpdateSection(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, http.StatusOK, section)
 }
 
-// DeleteSection deletes a section and all its tasks
-func DeleteSection(w http.ResponseWriter, r *http.Request) {
-	sectionID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+// DeleteSection removes a section from its challenge. Its tasks are archived
+// with it, so the entries recorded against them are kept.
+func (h *Handler) DeleteSection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	// Check section ownership through challenge
-	if err := validateSectionOwnership(sectionID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Section not found")
-		return
-	}
-
-	// Start a transaction to delete tasks and section
-	tx, err := db.DB.Begin(r.Context())
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
-		return
-	}
-	defer tx.Rollback(r.Context())
-
-	// Delete tasks associated with the section
-	_, err = tx.Exec(r.Context(), "DELETE FROM tasks WHERE section_id = $1", sectionID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete tasks")
+	section, ok := h.ownedSection(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	// Delete the section
-	_, err = tx.Exec(r.Context(), "DELETE FROM sections WHERE id = $1", sectionID)
-	if err != nil {
+	// Its tasks are archived rather than deleted so their entries survive
+	if err := h.store.ArchiveSection(r.Context(), section.ID); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to delete section")
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
-		return
-	}
-
 	utils.Success(w, http.StatusOK, map[string]string{"message": "Section deleted successfully"})
 }
 
 // ReorderSection changes the order of a section
-func ReorderSection(w http.ResponseWriter, r *http.Request) {
-	sectionID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+func (h *Handler) ReorderSection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	// Check section ownership through challenge
-	if err := validateSectionOwnership(sectionID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Section not found")
+	section, ok := h.ownedSection(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
@@ -286,119 +196,177 @@ func ReorderSection(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the section's challenge ID
-	var challengeID string
-	err := db.DB.QueryRow(r.Context(), "SELECT challenge_id FROM sections WHERE id = $1", sectionID).Scan(&challengeID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get section info")
+	if err := h.store.ReorderSection(r.Context(), section.ID, req.Order); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update section order")
 		return
 	}
 
-	// Update the section's order
-	tx, err := db.DB.Begin(r.Context())
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Section reordered successfully"})
+}
+
+// MoveSection moves a section and its tasks to another of the user's
+// challenges. The tasks keep their IDs, so their entries stay with them; a
+// section whose tasks have entries can't be moved.
+func (h *Handler) MoveSection(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
-	defer tx.Rollback(r.Context())
 
-	// Get current order
-	var currentOrder int
-	err = tx.QueryRow(r.Context(), `SELECT "order" FROM sections WHERE id = $1`, sectionID).Scan(&currentOrder)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get current order")
+	section, ok := h.ownedSection(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	// If moving up (smaller order number)
-	if req.Order < currentOrder {
-		_, err = tx.Exec(r.Context(), `
-			UPDATE sections
-			SET "order" = "order" + 1
-			WHERE challenge_id = $1 AND "order" >= $2 AND "order" < $3
-		`, challengeID, req.Order, currentOrder)
-	} else if req.Order > currentOrder {
-		// If moving down (larger order number)
-		_, err = tx.Exec(r.Context(), `
-			UPDATE sections
-			SET "order" = "order" - 1
-			WHERE challenge_id = $1 AND "order" > $2 AND "order" <= $3
-		`, challengeID, currentOrder, req.Order)
-	} else {
-		// No change needed
-		utils.Success(w, http.StatusOK, map[string]string{"message": "Order unchanged"})
+	var req MoveSectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
+	challengeID, err := uuid.Parse(req.ChallengeID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to update order of other sections")
+		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
 		return
 	}
 
-	// Update the section's order
-	_, err = tx.Exec(r.Context(), `UPDATE sections SET "order" = $1 WHERE id = $2`, req.Order, sectionID)
+	// The target has to belong to the user as well
+	target, err := h.store.GetChallenge(r.Context(), principal.UserID, challengeID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to update section order")
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Target challenge not found")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve challenge")
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
+	if err := h.store.MoveSection(r.Context(), section.ID, target.ID, req.Order); err != nil {
+		if errors.Is(err, store.ErrHasEntries) {
+			utils.Error(w, http.StatusBadRequest, "Section has tasks with entries and can't be moved to another challenge")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to move section")
 		return
 	}
 
-	utils.Success(w, http.StatusOK, map[string]string{"message": "Section reordered successfully"})
+	moved, err := h.store.GetSection(r.Context(), principal.UserID, section.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve section")
+		return
+	}
+
+	utils.Success(w, http.StatusOK, moved)
 }
 
-// Helper function to validate challenge ownership
-func validateChallengeOwnership(challengeID string, userID string) error {
-	// Parse UUID
-	_, err := uuid.Parse(challengeID)
-	if err != nil {
-		return err
+// ReorderSections puts every section of a challenge in a new order at once
+func (h *Handler) ReorderSections(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
 	}
 
-	// Check if challenge exists and belongs to user
-	var count int
-	err = db.DB.QueryRow(context.Background(), `
-		SELECT COUNT(*) FROM challenges WHERE id = $1 AND user_id = (SELECT id FROM users WHERE clerk_id = $2)
-	`, challengeID, userID).Scan(&count)
+	challenge, ok := h.ownedChallenge(w, r, principal, "challengeId")
+	if !ok {
+		return
+	}
+
+	ids, ok := decodeOrder(w, r)
+	if !ok {
+		return
+	}
 
+	if err := h.store.SetSectionOrder(r.Context(), challenge.ID, ids); err != nil {
+		if errors.Is(err, store.ErrOrderMismatch) {
+			utils.ValidationError(w, "Invalid order", []utils.FieldError{{
+				Field:   "ids",
+				Message: "must list every section of the challenge exactly once",
+			}})
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to update section order")
+		return
+	}
+
+	sections, err := h.store.ListSections(r.Context(), challenge.ID)
 	if err != nil {
-		return err
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve sections")
+		return
 	}
 
-	if count == 0 {
-		return errors.New("challenge not found or doesn't belong to user")
+	utils.Success(w, http.StatusOK, sections)
+}
+
+// decodeOrder reads an OrderRequest, writing a 422 when an ID is malformed
+func decodeOrder(w http.ResponseWriter, r *http.Request) ([]uuid.UUID, bool) {
+	var req OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
 	}
 
-	return nil
+	var fieldErrors []utils.FieldError
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	for i, raw := range req.IDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			fieldErrors = append(fieldErrors, utils.FieldError{Field: fmt.Sprintf("ids[%d]", i), Message: "must be a UUID"})
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	if len(fieldErrors) > 0 {
+		utils.ValidationError(w, "Invalid order", fieldErrors)
+		return nil, false
+	}
+
+	return ids, true
 }
 
-// Helper function to validate section ownership
-func validateSectionOwnership(sectionID string, userID string) error {
-	// Parse UUID
-	_, err := uuid.Parse(sectionID)
+// ownedChallenge loads the challenge named by the URL parameter param and
+// checks that it belongs to the principal. It writes the error response and
+// returns false when it doesn't.
+func (h *Handler) ownedChallenge(w http.ResponseWriter, r *http.Request, principal *auth.Principal, param string) (*models.Challenge, bool) {
+	challengeID, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid challenge ID")
+		return nil, false
+	}
+
+	challenge, err := h.store.GetChallenge(r.Context(), principal.UserID, challengeID)
 	if err != nil {
-		return err
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Challenge not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve challenge")
+		return nil, false
 	}
 
-	// Check if section exists and belongs to user's challenge
-	var count int
-	err = db.DB.QueryRow(context.Background(), `
-		SELECT COUNT(*) FROM sections s
-		JOIN challenges c ON s.challenge_id = c.id
-		JOIN users u ON c.user_id = u.id
-		WHERE s.id = $1 AND u.clerk_id = $2
-	`, sectionID, userID).Scan(&count)
+	return challenge, true
+}
 
+// ownedSection loads the section named by the URL parameter param and checks
+// that its challenge belongs to the principal
+func (h *Handler) ownedSection(w http.ResponseWriter, r *http.Request, principal *auth.Principal, param string) (*models.Section, bool) {
+	sectionID, err := uuid.Parse(chi.URLParam(r, param))
 	if err != nil {
-		return err
+		utils.Error(w, http.StatusBadRequest, "Invalid section ID")
+		return nil, false
 	}
 
-	if count == 0 {
-		return errors.New("section not found or doesn't belong to user's challenge")
+	section, err := h.store.GetSection(r.Context(), principal.UserID, sectionID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Section not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve section")
+		return nil, false
 	}
 
-	return nil
+	return section, true
 }
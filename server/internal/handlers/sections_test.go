@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+func TestMoveSection(t *testing.T) {
+	principal := newPrincipal()
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	t.Run("to another challenge", func(t *testing.T) {
+		m := newMemStore()
+		section := m.addSection(m.addChallenge(principal.UserID, start), "Morning")
+		target := m.addChallenge(principal.UserID, start)
+
+		var moved models.Section
+		w := serveJSON(New(m, nil).MoveSection, principal, http.MethodPost,
+			fmt.Sprintf(`{"challenge_id": %q}`, target.ID), "id", section.ID.String())
+		decode(t, w, http.StatusOK, &moved)
+		if moved.ChallengeID != target.ID {
+			t.Errorf("section is in challenge %s, want %s", moved.ChallengeID, target.ID)
+		}
+	})
+
+	t.Run("tasks with entries", func(t *testing.T) {
+		m := newMemStore()
+		section := m.addSection(m.addChallenge(principal.UserID, start), "Morning")
+		target := m.addChallenge(principal.UserID, start)
+		m.moveErr = store.ErrHasEntries
+
+		w := serveJSON(New(m, nil).MoveSection, principal, http.MethodPost,
+			fmt.Sprintf(`{"challenge_id": %q}`, target.ID), "id", section.ID.String())
+		decode(t, w, http.StatusBadRequest, nil)
+	})
+
+	t.Run("challenge of another user", func(t *testing.T) {
+		m := newMemStore()
+		section := m.addSection(m.addChallenge(principal.UserID, start), "Morning")
+		other := m.addChallenge(newPrincipal().UserID, start)
+
+		w := serveJSON(New(m, nil).MoveSection, principal, http.MethodPost,
+			fmt.Sprintf(`{"challenge_id": %q}`, other.ID), "id", section.ID.String())
+		decode(t, w, http.StatusNotFound, nil)
+	})
+}
@@ -1,120 +1,91 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/hari4698/hardinfinity/internal/auth"
-	"github.com/hari4698/hardinfinity/internal/db"
 	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/tasktypes"
 	"github.com/hari4698/hardinfinity/internal/utils"
 )
 
 type CreateTaskRequest struct {
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	TaskType      string `json:"task_type"`
-	Required      bool   `json:"required"`
-	RestartOnFail bool   `json:"restart_on_fail"`
-	StrikesEnabled bool   `json:"strikes_enabled"`
-	StrikesLimit  int    `json:"strikes_limit"`
-	Order         int    `json:"order"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description"`
+	TaskType       string              `json:"task_type"`
+	Required       bool                `json:"required"`
+	RestartOnFail  bool                `json:"restart_on_fail"`
+	StrikesEnabled bool                `json:"strikes_enabled"`
+	StrikesLimit   int                 `json:"strikes_limit"`
+	Config         models.TaskConfig   `json:"config"`
+	Schedule       models.TaskSchedule `json:"schedule"`
+	Order          int                 `json:"order"`
 }
 
 type UpdateTaskRequest struct {
-	Name          string `json:"name"`
-	Description   string `json:"description"`
-	TaskType      string `json:"task_type"`
-	Required      bool   `json:"required"`
-	RestartOnFail bool   `json:"restart_on_fail"`
-	StrikesEnabled bool   `json:"strikes_enabled"`
-	StrikesLimit  int    `json:"strikes_limit"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description"`
+	TaskType       string              `json:"task_type"`
+	Required       bool                `json:"required"`
+	RestartOnFail  bool                `json:"restart_on_fail"`
+	StrikesEnabled bool                `json:"strikes_enabled"`
+	StrikesLimit   int                 `json:"strikes_limit"`
+	Config         models.TaskConfig   `json:"config"`
+	Schedule       models.TaskSchedule `json:"schedule"`
 }
 
 type ReorderTaskRequest struct {
 	Order int `json:"order"`
 }
 
-// GetTasks retrieves all tasks for a section
-func GetTasks(w http.ResponseWriter, r *http.Request) {
-	sectionID := chi.URLParam(r, "id")
+// MoveTaskRequest names the section a task moves to and its position there.
+// A missing or non-positive order appends the task.
+type MoveTaskRequest struct {
+	SectionID string `json:"section_id"`
+	Order     int    `json:"order"`
+}
 
-	// Verify that the section exists and belongs to the authenticated user
-	userID, ok := auth.GetUserID(r.Context())
+// GetTasks retrieves all tasks for a section
+func (h *Handler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Check section ownership
-	if err := validateSectionOwnership(sectionID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Section not found")
+	// Verify that the section exists and belongs to the authenticated user
+	section, ok := h.ownedSection(w, r, principal, "sectionId")
+	if !ok {
 		return
 	}
 
-	// Query the database for tasks
-	rows, err := db.DB.Query(r.Context(), `
-		SELECT id, section_id, name, description, task_type, required, restart_on_fail, 
-		       strikes_enabled, strikes_limit, "order", created_at, updated_at
-		FROM tasks
-		WHERE section_id = $1
-		ORDER BY "order" ASC
-	`, sectionID)
+	tasks, err := h.store.ListTasks(r.Context(), section.ID)
 	if err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve tasks")
 		return
 	}
-	defer rows.Close()
-
-	tasks := []models.Task{}
-	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(
-			&task.ID,
-			&task.SectionID,
-			&task.Name,
-			&task.Description,
-			&task.TaskType,
-			&task.Required,
-			&task.RestartOnFail,
-			&task.StrikesEnabled,
-			&task.StrikesLimit,
-			&task.Order,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Error scanning task data")
-			return
-		}
-		tasks = append(tasks, task)
-	}
-
-	if err := rows.Err(); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Error iterating through tasks")
-		return
-	}
 
 	utils.Success(w, http.StatusOK, tasks)
 }
 
 // CreateTask creates a new task for a section
-func CreateTask(w http.ResponseWriter, r *http.Request) {
-	sectionID := chi.URLParam(r, "id")
-
-	// Verify that the section exists and belongs to the authenticated user
-	userID, ok := auth.GetUserID(r.Context())
+func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Check section ownership
-	if err := validateSectionOwnership(sectionID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Section not found")
+	// Verify that the section exists and belongs to the authenticated user
+	section, ok := h.ownedSection(w, r, principal, "sectionId")
+	if !ok {
 		return
 	}
 
@@ -141,47 +112,26 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 		req.StrikesLimit = 3 // Default to 3 strikes if enabled but no limit specified
 	}
 
-	// Generate a new UUID for the task
-	taskID := uuid.New().String()
+	// A non-positive order appends the task after the existing ones
+	task := models.Task{
+		SectionID:      section.ID,
+		Name:           req.Name,
+		Description:    req.Description,
+		TaskType:       req.TaskType,
+		Required:       req.Required,
+		RestartOnFail:  req.RestartOnFail,
+		StrikesEnabled: req.StrikesEnabled,
+		StrikesLimit:   req.StrikesLimit,
+		Config:         req.Config,
+		Schedule:       req.Schedule,
+		Order:          req.Order,
+	}
 
-	// If order is not specified, determine the next available order
-	if req.Order <= 0 {
-		var maxOrder int
-		err := db.DB.QueryRow(r.Context(), `
-			SELECT COALESCE(MAX("order"), 0) FROM tasks WHERE section_id = $1
-		`, sectionID).Scan(&maxOrder)
-		if err != nil {
-			utils.Error(w, http.StatusInternalServerError, "Failed to determine task order")
-			return
-		}
-		req.Order = maxOrder + 1
-	}
-
-	// Insert the new task
-	var task models.Task
-	err := db.DB.QueryRow(r.Context(), `
-		INSERT INTO tasks (id, section_id, name, description, task_type, required, restart_on_fail, 
-		                  strikes_enabled, strikes_limit, "order", created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
-		RETURNING id, section_id, name, description, task_type, required, restart_on_fail, 
-		          strikes_enabled, strikes_limit, "order", created_at, updated_at
-	`, taskID, sectionID, req.Name, req.Description, req.TaskType, req.Required, req.RestartOnFail,
-		req.StrikesEnabled, req.StrikesLimit, req.Order).Scan(
-		&task.ID,
-		&task.SectionID,
-		&task.Name,
-		&task.Description,
-		&task.TaskType,
-		&task.Required,
-		&task.RestartOnFail,
-		&task.StrikesEnabled,
-		&task.StrikesLimit,
-		&task.Order,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	if !validTaskConfig(w, &task) {
+		return
+	}
 
-	if err != nil {
+	if err := h.store.CreateTask(r.Context(), &task); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to create task")
 		return
 	}
@@ -190,19 +140,16 @@ func CreateTask(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateTask updates an existing task
-func UpdateTask(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	// Check task ownership through section and challenge
-	if err := validateTaskOwnership(taskID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Task not found")
+	task, ok := h.ownedTask(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
@@ -229,32 +176,21 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 		req.StrikesLimit = 3 // Default to 3 strikes if enabled but no limit specified
 	}
 
-	// Update the task
-	var task models.Task
-	err := db.DB.QueryRow(r.Context(), `
-		UPDATE tasks
-		SET name = $1, description = $2, task_type = $3, required = $4, restart_on_fail = $5,
-		    strikes_enabled = $6, strikes_limit = $7, updated_at = NOW()
-		WHERE id = $8
-		RETURNING id, section_id, name, description, task_type, required, restart_on_fail, 
-		          strikes_enabled, strikes_limit, "order", created_at, updated_at
-	`, req.Name, req.Description, req.TaskType, req.Required, req.RestartOnFail,
-		req.StrikesEnabled, req.StrikesLimit, taskID).Scan(
-		&task.ID,
-		&task.SectionID,
-		&task.Name,
-		&task.Description,
-		&task.TaskType,
-		&task.Required,
-		&task.RestartOnFail,
-		&task.StrikesEnabled,
-		&task.StrikesLimit,
-		&task.Order,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
+	task.Name = req.Name
+	task.Description = req.Description
+	task.TaskType = req.TaskType
+	task.Required = req.Required
+	task.RestartOnFail = req.RestartOnFail
+	task.StrikesEnabled = req.StrikesEnabled
+	task.StrikesLimit = req.StrikesLimit
+	task.Config = req.Config
+	task.Schedule = req.Schedule
 
-	if err != nil {
+	if !validTaskConfig(w, task) {
+		return
+	}
+
+	if err := h.store.UpdateTask(r.Context(), task); err != nil {
 		utils.Error(w, http.StatusInternalServerError, "Failed to update task")
 		return
 	}
@@ -262,181 +198,251 @@ func UpdateTask(w http.ResponseWriter, r *http.Request) {
 	utils.Success(w, http.StatusOK, task)
 }
 
-// DeleteTask deletes a task
-func DeleteTask(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+// DeleteTask archives a task. It disappears from the challenge, but the
+// entries recorded against it are kept.
+func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	// Check task ownership through section and challenge
-	if err := validateTaskOwnership(taskID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Task not found")
+	task, ok := h.ownedTask(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	// Get the section ID and current order for reordering remaining tasks
-	var sectionID string
-	var currentOrder int
-	err := db.DB.QueryRow(r.Context(), `
-		SELECT section_id, "order" FROM tasks WHERE id = $1
-	`, taskID).Scan(&sectionID, &currentOrder)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get task info")
+	if err := h.store.ArchiveTask(r.Context(), task.ID); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to delete task")
 		return
 	}
 
-	// Start a transaction to delete the task and update orders
-	tx, err := db.DB.Begin(r.Context())
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Task archived successfully"})
+}
+
+func (h *Handler) ReorderTask(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
-	defer tx.Rollback(r.Context())
 
-	// Delete the task
-	_, err = tx.Exec(r.Context(), "DELETE FROM tasks WHERE id = $1", taskID)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to delete task")
+	// Check task ownership through section and challenge
+	task, ok := h.ownedTask(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	// Update order of remaining tasks
-	_, err = tx.Exec(r.Context(), `
-		UPDATE tasks
-		SET "order" = "order" - 1
-		WHERE section_id = $1 AND "order" > $2
-	`, sectionID, currentOrder)
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to update task orders")
+	// Parse request body
+	var req ReorderTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Order <= 0 {
+		utils.Error(w, http.StatusBadRequest, "Order must be a positive integer")
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
+	if err := h.store.ReorderTask(r.Context(), task.ID, req.Order); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update task order")
 		return
 	}
 
-	utils.Success(w, http.StatusOK, map[string]string{"message": "Task deleted successfully"})
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Task reordered successfully"})
 }
 
-func ReorderTask(w http.ResponseWriter, r *http.Request) {
-	taskID := chi.URLParam(r, "id")
-
-	// Verify user is authenticated
-	userID, ok := auth.GetUserID(r.Context())
+// MoveTask moves a task to another section, which may belong to another of
+// the user's challenges. The task keeps its ID, so its entries stay with it;
+// a task that has entries can only move within their challenge.
+func (h *Handler) MoveTask(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
 	if !ok {
 		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	// Check task ownership through section and challenge
-	if err := validateTaskOwnership(taskID, userID); err != nil {
-		utils.Error(w, http.StatusNotFound, "Task not found")
+	task, ok := h.ownedTask(w, r, principal, "id")
+	if !ok {
 		return
 	}
 
-	// Parse request body
-	var req ReorderTaskRequest
+	var req MoveTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.Error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.Order <= 0 {
-		utils.Error(w, http.StatusBadRequest, "Order must be a positive integer")
+	sectionID, err := uuid.Parse(req.SectionID)
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid section ID")
 		return
 	}
 
-	// Get the task's section ID and current order
-	var sectionID string
-	var currentOrder int
-	err := db.DB.QueryRow(r.Context(), `
-		SELECT section_id, "order" FROM tasks WHERE id = $1
-	`, taskID).Scan(&sectionID, &currentOrder)
+	// The target has to belong to the user as well
+	target, err := h.store.GetSection(r.Context(), principal.UserID, sectionID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to get task info")
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Target section not found")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve section")
 		return
 	}
 
-	// Update the task's order
-	tx, err := db.DB.Begin(r.Context())
+	if err := h.store.MoveTask(r.Context(), task.ID, target.ID, req.Order); err != nil {
+		if errors.Is(err, store.ErrHasEntries) {
+			utils.Error(w, http.StatusBadRequest, "Task has entries and can't be moved to another challenge")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to move task")
+		return
+	}
+
+	moved, err := h.store.GetTask(r.Context(), principal.UserID, task.ID)
 	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to start transaction")
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task")
 		return
 	}
-	defer tx.Rollback(r.Context())
 
-	// If moving up (smaller order number)
-	if req.Order < currentOrder {
-		_, err = tx.Exec(r.Context(), `
-			UPDATE tasks
-			SET "order" = "order" + 1
-			WHERE section_id = $1 AND "order" >= $2 AND "order" < $3
-		`, sectionID, req.Order, currentOrder)
-	} else if req.Order > currentOrder {
-		// If moving down (larger order number)
-		_, err = tx.Exec(r.Context(), `
-			UPDATE tasks
-			SET "order" = "order" - 1
-			WHERE section_id = $1 AND "order" > $2 AND "order" <= $3
-		`, sectionID, currentOrder, req.Order)
-	} else {
-		// No change needed
-		utils.Success(w, http.StatusOK, map[string]string{"message": "Order unchanged"})
+	utils.Success(w, http.StatusOK, moved)
+}
+
+// ReorderTasks puts every task of a section in a new order at once
+func (h *Handler) ReorderTasks(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
-	if err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to update order of other tasks")
+	section, ok := h.ownedSection(w, r, principal, "sectionId")
+	if !ok {
 		return
 	}
 
-	// Update the task's order
-	_, err = tx.Exec(r.Context(), `
-		UPDATE tasks SET "order" = $1 WHERE id = $2
-	`, req.Order, taskID)
-	if err != nil {
+	ids, ok := decodeOrder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.store.SetTaskOrder(r.Context(), section.ID, ids); err != nil {
+		if errors.Is(err, store.ErrOrderMismatch) {
+			utils.ValidationError(w, "Invalid order", []utils.FieldError{{
+				Field:   "ids",
+				Message: "must list every task of the section exactly once",
+			}})
+			return
+		}
 		utils.Error(w, http.StatusInternalServerError, "Failed to update task order")
 		return
 	}
 
-	if err := tx.Commit(r.Context()); err != nil {
-		utils.Error(w, http.StatusInternalServerError, "Failed to commit transaction")
+	tasks, err := h.store.ListTasks(r.Context(), section.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve tasks")
 		return
 	}
 
-	utils.Success(w, http.StatusOK, map[string]string{"message": "Task reordered successfully"})
+	utils.Success(w, http.StatusOK, tasks)
 }
 
-func validateTaskOwnership(taskID string, userID string) error {
-	// Parse UUID
-	_, err := uuid.Parse(taskID)
+// ownedTask loads the task named by the URL parameter param and checks that
+// its challenge belongs to the principal
+func (h *Handler) ownedTask(w http.ResponseWriter, r *http.Request, principal *auth.Principal, param string) (*models.Task, bool) {
+	taskID, err := uuid.Parse(chi.URLParam(r, param))
 	if err != nil {
-		return err
+		utils.Error(w, http.StatusBadRequest, "Invalid task ID")
+		return nil, false
 	}
 
-	// Check if task exists and belongs to user's challenge
-	var count int
-	err = db.DB.QueryRow(context.Background(), `
-		SELECT COUNT(*) FROM tasks t
-		JOIN sections s ON t.section_id = s.id
-		JOIN challenges c ON s.challenge_id = c.id
-		JOIN users u ON c.user_id = u.id
-		WHERE t.id = $1 AND u.clerk_id = $2
-	`, taskID, userID).Scan(&count)
-
+	task, err := h.store.GetTask(r.Context(), principal.UserID, taskID)
 	if err != nil {
-		return err
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Task not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve task")
+		return nil, false
+	}
+
+	return task, true
+}
+
+// taskHistory holds every revision of a challenge's tasks, by task ID and
+// then revision number
+type taskHistory map[uuid.UUID]map[int]*models.Task
+
+func newTaskHistory(revisions []models.Task) taskHistory {
+	history := taskHistory{}
+	for i := range revisions {
+		rev := &revisions[i]
+		if history[rev.ID] == nil {
+			history[rev.ID] = map[int]*models.Task{}
+		}
+		history[rev.ID][rev.Revision] = rev
+	}
+	return history
+}
+
+// attach points each task entry at the task as it was when it was recorded
+func (th taskHistory) attach(taskEntries []models.TaskEntry) {
+	for i := range taskEntries {
+		taskEntries[i].Task = th[taskEntries[i].TaskID][taskEntries[i].TaskRevision]
+	}
+}
+
+// former returns the latest revision of every task that has one of
+// taskEntries recorded against it but is not among current: it was archived
+// or moved to another challenge
+func (th taskHistory) former(taskEntries []models.TaskEntry, current []models.Task) []models.Task {
+	seen := make(map[uuid.UUID]bool, len(current))
+	for _, task := range current {
+		seen[task.ID] = true
+	}
+
+	tasks := []models.Task{}
+	for _, te := range taskEntries {
+		if seen[te.TaskID] {
+			continue
+		}
+		seen[te.TaskID] = true
+
+		var latest *models.Task
+		for _, rev := range th[te.TaskID] {
+			if latest == nil || rev.Revision > latest.Revision {
+				latest = rev
+			}
+		}
+		if latest != nil {
+			tasks = append(tasks, *latest)
+		}
+	}
+	return tasks
+}
+
+// validTaskConfig checks the type, settings and schedule of a task, writing a
+// 422 when they are rejected
+func validTaskConfig(w http.ResponseWriter, task *models.Task) bool {
+	if _, ok := tasktypes.Lookup(task.TaskType); !ok {
+		utils.ValidationError(w, "Invalid task", []utils.FieldError{{
+			Field:   "task_type",
+			Message: "must be one of " + strings.Join(tasktypes.Names(), ", "),
+		}})
+		return false
 	}
 
-	if count == 0 {
-		return errors.New("task not found or doesn't belong to user's challenge")
+	if err := tasktypes.ValidateConfig(task); err != nil {
+		utils.ValidationError(w, "Invalid task", []utils.FieldError{{Field: "config", Message: err.Error()}})
+		return false
 	}
 
-	return nil
-}
\ No newline at end of file
+	if err := schedule.Validate(task.Schedule); err != nil {
+		utils.ValidationError(w, "Invalid task", []utils.FieldError{{Field: "schedule", Message: err.Error()}})
+		return false
+	}
+
+	return true
+}
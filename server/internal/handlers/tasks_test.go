@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+func TestMoveTask(t *testing.T) {
+	principal := newPrincipal()
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	setup := func() (*memStore, *models.Task, *models.Section) {
+		m := newMemStore()
+		from := m.addSection(m.addChallenge(principal.UserID, start), "Morning")
+		to := m.addSection(m.addChallenge(principal.UserID, start), "Evening")
+		return m, m.addTask(from, "Read"), to
+	}
+	move := func(m *memStore, task *models.Task, body string) *httptest.ResponseRecorder {
+		return serveJSON(New(m, nil).MoveTask, principal, http.MethodPost, body, "id", task.ID.String())
+	}
+
+	t.Run("to a section of another challenge", func(t *testing.T) {
+		m, task, to := setup()
+
+		var moved models.Task
+		decode(t, move(m, task, fmt.Sprintf(`{"section_id": %q, "order": 1}`, to.ID)), http.StatusOK, &moved)
+		if moved.ID != task.ID || moved.SectionID != to.ID {
+			t.Errorf("moved task %s into %s, want %s into %s", moved.ID, moved.SectionID, task.ID, to.ID)
+		}
+	})
+
+	t.Run("task with entries", func(t *testing.T) {
+		m, task, to := setup()
+		m.moveErr = store.ErrHasEntries
+
+		decode(t, move(m, task, fmt.Sprintf(`{"section_id": %q}`, to.ID)), http.StatusBadRequest, nil)
+	})
+
+	t.Run("section of another user", func(t *testing.T) {
+		m, task, _ := setup()
+		other := m.addSection(m.addChallenge(newPrincipal().UserID, start), "Theirs")
+
+		decode(t, move(m, task, fmt.Sprintf(`{"section_id": %q}`, other.ID)), http.StatusNotFound, nil)
+		if m.tasks[task.ID].SectionID == other.ID {
+			t.Error("task moved into a section of another user")
+		}
+	})
+
+	t.Run("invalid section ID", func(t *testing.T) {
+		m, task, _ := setup()
+
+		decode(t, move(m, task, `{"section_id": "nope"}`), http.StatusBadRequest, nil)
+	})
+}
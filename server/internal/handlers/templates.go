@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/templates"
+	"github.com/hari4698/hardinfinity/internal/utils"
+)
+
+type CreateTemplateRequest struct {
+	ChallengeID uuid.UUID `json:"challenge_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+}
+
+type InstantiateTemplateRequest struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	StartDate    time.Time `json:"start_date"`
+	DurationDays int       `json:"duration_days"`
+}
+
+// ListTemplates returns the built-in templates followed by the user's own
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	private, err := h.store.ListTemplates(r.Context(), principal.UserID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve templates")
+		return
+	}
+
+	utils.Success(w, http.StatusOK, append(templates.Builtin(), private...))
+}
+
+// GetTemplate retrieves a built-in or private template by ID
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	template, ok := h.findTemplate(w, r, principal)
+	if !ok {
+		return
+	}
+
+	utils.Success(w, http.StatusOK, template)
+}
+
+// CreateTemplate saves the sections and tasks of an existing challenge as a
+// private template
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req CreateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	challenge, err := h.store.GetChallenge(r.Context(), principal.UserID, req.ChallengeID)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "Challenge not found")
+		return
+	}
+
+	if req.Name == "" {
+		req.Name = challenge.Name
+	}
+
+	sections, err := challengeStructure(r.Context(), h.store, challenge.ID)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to read challenge structure")
+		return
+	}
+
+	template := models.Template{
+		UserID:       &principal.UserID,
+		Name:         req.Name,
+		Description:  req.Description,
+		DurationDays: challenge.DurationDays,
+		Sections:     sections,
+	}
+
+	if err := h.store.CreateTemplate(r.Context(), &template); err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to save template")
+		return
+	}
+
+	utils.Success(w, http.StatusCreated, template)
+}
+
+// DeleteTemplate deletes a private template
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	templateID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	if _, ok := templates.FindBuiltin(templateID); ok {
+		utils.Error(w, http.StatusForbidden, "Built-in templates cannot be deleted")
+		return
+	}
+
+	if err := h.store.DeleteTemplate(r.Context(), principal.UserID, templateID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Template not found")
+			return
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to delete template")
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Template deleted successfully"})
+}
+
+// InstantiateTemplate creates a new active challenge with the template's
+// sections and tasks in a single transaction. The body is optional; fields
+// left out are taken from the template.
+func (h *Handler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	template, ok := h.findTemplate(w, r, principal)
+	if !ok {
+		return
+	}
+
+	var req InstantiateTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	challenge := models.Challenge{
+		ID:           uuid.New(),
+		UserID:       principal.UserID,
+		Name:         req.Name,
+		Description:  req.Description,
+		StartDate:    req.StartDate,
+		DurationDays: req.DurationDays,
+		CurrentDay:   1,
+		Status:       "active",
+	}
+
+	if challenge.Name == "" {
+		challenge.Name = template.Name
+	}
+	if challenge.Description == "" {
+		challenge.Description = template.Description
+	}
+	if challenge.StartDate.IsZero() {
+		challenge.StartDate = dates.Today(principal.Timezone)
+	}
+	if challenge.DurationDays == 0 {
+		challenge.DurationDays = template.DurationDays
+	}
+
+	if err := normalizeChallengeLength(&challenge); err != nil {
+		utils.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err := h.store.WithTx(r.Context(), func(tx store.Store) error {
+		if err := tx.CreateChallenge(r.Context(), &challenge); err != nil {
+			return err
+		}
+		return createChallengeStructure(r.Context(), tx, challenge.ID, template.Sections)
+	})
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to create challenge from template")
+		return
+	}
+
+	utils.Success(w, http.StatusCreated, challenge)
+}
+
+// findTemplate resolves the "id" URL parameter to a built-in template or one
+// of the principal's private templates
+func (h *Handler) findTemplate(w http.ResponseWriter, r *http.Request, principal *auth.Principal) (*models.Template, bool) {
+	templateID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid template ID")
+		return nil, false
+	}
+
+	if template, ok := templates.FindBuiltin(templateID); ok {
+		return template, true
+	}
+
+	template, err := h.store.GetTemplate(r.Context(), principal.UserID, templateID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			utils.Error(w, http.StatusNotFound, "Template not found")
+			return nil, false
+		}
+		utils.Error(w, http.StatusInternalServerError, "Failed to retrieve template")
+		return nil, false
+	}
+
+	return template, true
+}
+
+// challengeStructure captures the sections and tasks of a challenge, in order
+func challengeStructure(ctx context.Context, s store.Store, challengeID uuid.UUID) ([]models.TemplateSection, error) {
+	sections, err := s.ListSections(ctx, challengeID)
+	if err != nil {
+		return nil, err
+	}
+
+	structure := make([]models.TemplateSection, 0, len(sections))
+	for _, section := range sections {
+		tasks, err := s.ListTasks(ctx, section.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		templateSection := models.TemplateSection{
+			Name:        section.Name,
+			Description: section.Description,
+			Tasks:       make([]models.TemplateTask, 0, len(tasks)),
+		}
+		for _, task := range tasks {
+			templateSection.Tasks = append(templateSection.Tasks, models.TemplateTask{
+				Name:           task.Name,
+				Description:    task.Description,
+				TaskType:       task.TaskType,
+				Required:       task.Required,
+				RestartOnFail:  task.RestartOnFail,
+				StrikesEnabled: task.StrikesEnabled,
+				StrikesLimit:   task.StrikesLimit,
+				Config:         task.Config,
+				Schedule:       task.Schedule,
+			})
+		}
+		structure = append(structure, templateSection)
+	}
+
+	return structure, nil
+}
+
+// createChallengeStructure adds the given sections and tasks to a challenge,
+// numbering them in the order given
+func createChallengeStructure(ctx context.Context, tx store.Store, challengeID uuid.UUID, sections []models.TemplateSection) error {
+	for i, templateSection := range sections {
+		section := models.Section{
+			ChallengeID: challengeID,
+			Name:        templateSection.Name,
+			Description: templateSection.Description,
+			Order:       i + 1,
+		}
+		if err := tx.CreateSection(ctx, &section); err != nil {
+			return err
+		}
+
+		for j, templateTask := range templateSection.Tasks {
+			task := models.Task{
+				SectionID:      section.ID,
+				Name:           templateTask.Name,
+				Description:    templateTask.Description,
+				TaskType:       templateTask.TaskType,
+				Required:       templateTask.Required,
+				RestartOnFail:  templateTask.RestartOnFail,
+				StrikesEnabled: templateTask.StrikesEnabled,
+				StrikesLimit:   templateTask.StrikesLimit,
+				Config:         templateTask.Config,
+				Schedule:       templateTask.Schedule,
+				Order:          j + 1,
+			}
+			if err := tx.CreateTask(ctx, &task); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
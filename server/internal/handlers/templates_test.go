@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	_ "time/tzdata" // the user's timezone is a named one
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/templates"
+)
+
+func TestInstantiateTemplate(t *testing.T) {
+	principal := newPrincipal()
+	principal.Timezone = "Pacific/Kiritimati"
+
+	m := newMemStore()
+	private := &models.Template{
+		ID:           uuid.New(),
+		UserID:       &principal.UserID,
+		Name:         "Soft 30",
+		Description:  "A gentler month",
+		DurationDays: 30,
+		Sections: []models.TemplateSection{
+			{Name: "Body", Tasks: []models.TemplateTask{{Name: "Walk", TaskType: "boolean", Required: true}}},
+			{Name: "Mind", Tasks: []models.TemplateTask{{Name: "Read", TaskType: "number"}, {Name: "Journal", TaskType: "text"}}},
+		},
+	}
+	m.templates[private.ID] = private
+
+	// sectionsOf returns the number of sections and tasks of a challenge
+	sectionsOf := func(challengeID uuid.UUID) (int, int) {
+		sections, _ := m.ListSections(context.Background(), challengeID)
+		tasks := 0
+		for _, section := range sections {
+			list, _ := m.ListTasks(context.Background(), section.ID)
+			tasks += len(list)
+		}
+		return len(sections), tasks
+	}
+
+	t.Run("empty body", func(t *testing.T) {
+		var challenge models.Challenge
+		w := serveJSON(New(m, nil).InstantiateTemplate, principal, http.MethodPost, "", "id", private.ID.String())
+		decode(t, w, http.StatusCreated, &challenge)
+
+		if challenge.Name != private.Name || challenge.Description != private.Description || challenge.DurationDays != 30 {
+			t.Errorf("challenge = %+v, want the template's name, description and length", challenge)
+		}
+		if today := dates.Today(principal.Timezone); !challenge.StartDate.Equal(today) {
+			t.Errorf("challenge starts on %s, want today in the user's timezone, %s", dates.Format(challenge.StartDate), dates.Format(today))
+		}
+		if sections, tasks := sectionsOf(challenge.ID); sections != 2 || tasks != 3 {
+			t.Errorf("challenge has %d sections and %d tasks, want 2 and 3", sections, tasks)
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		var challenge models.Challenge
+		w := serveJSON(New(m, nil).InstantiateTemplate, principal, http.MethodPost,
+			`{"name": "Autumn", "start_date": "2025-09-01T00:00:00Z", "duration_days": 10}`, "id", private.ID.String())
+		decode(t, w, http.StatusCreated, &challenge)
+
+		if challenge.Name != "Autumn" || challenge.DurationDays != 10 || dates.Format(challenge.StartDate) != "2025-09-01" {
+			t.Errorf("challenge = %+v, want the requested name, length and start", challenge)
+		}
+	})
+
+	t.Run("built-in template", func(t *testing.T) {
+		builtin := templates.Builtin()[0]
+
+		var challenge models.Challenge
+		w := serveJSON(New(m, nil).InstantiateTemplate, principal, http.MethodPost, "", "id", builtin.ID.String())
+		decode(t, w, http.StatusCreated, &challenge)
+
+		if sections, _ := sectionsOf(challenge.ID); sections != len(builtin.Sections) {
+			t.Errorf("challenge has %d sections, want %d", sections, len(builtin.Sections))
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		w := serveJSON(New(m, nil).InstantiateTemplate, principal, http.MethodPost, `{"name":`, "id", private.ID.String())
+		decode(t, w, http.StatusBadRequest, nil)
+	})
+
+	t.Run("template of another user", func(t *testing.T) {
+		w := serveJSON(New(m, nil).InstantiateTemplate, newPrincipal(), http.MethodPost, "", "id", private.ID.String())
+		decode(t, w, http.StatusNotFound, nil)
+	})
+}
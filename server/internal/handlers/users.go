@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/utils"
+)
+
+// UpdateUserRequest holds the user settings that can be changed through the
+// API. Email and name are synced from Clerk.
+type UpdateUserRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+// GetCurrentUser returns the authenticated user with their local date
+func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	user, err := h.store.GetUser(r.Context(), principal.UserID)
+	if err != nil {
+		utils.Error(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]any{
+		"user":       user,
+		"local_date": dates.Format(dates.Today(user.Timezone)),
+	})
+}
+
+// UpdateCurrentUser changes the authenticated user's timezone
+func (h *Handler) UpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		utils.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !dates.Valid(req.Timezone) {
+		utils.Error(w, http.StatusBadRequest, "Timezone must be an IANA timezone such as America/Los_Angeles")
+		return
+	}
+
+	user, err := h.store.UpdateUserTimezone(r.Context(), principal.UserID, req.Timezone)
+	if err != nil {
+		utils.Error(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	utils.Success(w, http.StatusOK, map[string]any{
+		"user":       user,
+		"local_date": dates.Format(dates.Today(user.Timezone)),
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/clerkinc/clerk-sdk-go/clerk"
+	"github.com/hari4698/hardinfinity/internal/auth"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+	"github.com/hari4698/hardinfinity/internal/utils"
+)
+
+// maxWebhookBody caps the size of an incoming webhook payload
+const maxWebhookBody = 1 << 20
+
+type clerkWebhookEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// ClerkWebhook keeps the users table in sync with Clerk user lifecycle events.
+// Deleting a user also removes the files they uploaded.
+func (h *Handler) ClerkWebhook(w http.ResponseWriter, r *http.Request) {
+	secret := os.Getenv("CLERK_WEBHOOK_SECRET")
+	if secret == "" {
+		utils.Error(w, http.StatusServiceUnavailable, "Webhook secret not configured")
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		utils.Error(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if err := auth.VerifyWebhook(secret, r.Header, payload); err != nil {
+		utils.Error(w, http.StatusUnauthorized, "Invalid webhook signature")
+		return
+	}
+
+	var event clerkWebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		utils.Error(w, http.StatusBadRequest, "Invalid webhook payload")
+		return
+	}
+
+	switch event.Type {
+	case "user.created", "user.updated":
+		var user clerk.User
+		if err := json.Unmarshal(event.Data, &user); err != nil || user.ID == "" {
+			utils.Error(w, http.StatusBadRequest, "Invalid user payload")
+			return
+		}
+
+		email, name := auth.UserProfile(&user)
+		if email == "" {
+			utils.Error(w, http.StatusUnprocessableEntity, "User has no email address")
+			return
+		}
+
+		if _, err := h.store.UpsertUser(r.Context(), user.ID, email, name); err != nil {
+			log.Printf("Clerk webhook %s: %v", event.Type, err)
+			utils.Error(w, http.StatusInternalServerError, "Failed to save user")
+			return
+		}
+
+	case "user.deleted":
+		var deleted struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(event.Data, &deleted); err != nil || deleted.ID == "" {
+			utils.Error(w, http.StatusBadRequest, "Invalid user payload")
+			return
+		}
+
+		// Attachments go with the user, so their files are removed too
+		var attachments []models.Attachment
+		err := h.store.WithTx(r.Context(), func(tx store.Store) error {
+			user, err := tx.GetUserByClerkID(r.Context(), deleted.ID)
+			if errors.Is(err, store.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if attachments, err = tx.ListUserAttachments(r.Context(), user.ID); err != nil {
+				return err
+			}
+			return tx.DeleteUser(r.Context(), deleted.ID)
+		})
+		if err != nil {
+			log.Printf("Clerk webhook %s: %v", event.Type, err)
+			utils.Error(w, http.StatusInternalServerError, "Failed to delete user")
+			return
+		}
+		h.deleteAttachmentBlobs(r.Context(), attachments)
+
+	default:
+		// Acknowledge events we don't handle so Svix doesn't retry them
+	}
+
+	utils.Success(w, http.StatusOK, map[string]string{"message": "Webhook processed"})
+}
@@ -0,0 +1,87 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// PanelHeight is the tallest a photo can be in a composite, in pixels
+const PanelHeight = 1024
+
+var (
+	background = color.RGBA{R: 0x11, G: 0x11, B: 0x11, A: 0xff}
+	foreground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// Panel is one photo of a composite with the label drawn under it
+type Panel struct {
+	Image *image.RGBA
+	Label string
+}
+
+// Compose lays panels out side by side on a dark background, scaled to a
+// common height of at most PanelHeight, with each label centred under its
+// photo
+func Compose(panels []Panel) *image.RGBA {
+	height := PanelHeight
+	for _, p := range panels {
+		height = min(height, p.Image.Bounds().Dy())
+	}
+	height = max(height, 1)
+
+	scaled := make([]*image.RGBA, len(panels))
+	gap := max(height/64, 4)
+	band := max(height/8, glyphHeight+2)
+	width := gap
+	for i, p := range panels {
+		w, h := p.Image.Bounds().Dx(), p.Image.Bounds().Dy()
+		scaled[i] = Resize(p.Image, max(w*height/h, 1), height)
+		width += scaled[i].Bounds().Dx() + gap
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, gap+height+band))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	// Labels take up about half the band
+	scale := max(band/2/glyphHeight, 1)
+	x := gap
+	for i, img := range scaled {
+		w := img.Bounds().Dx()
+		draw.Draw(dst, image.Rect(x, gap, x+w, gap+height), img, image.Point{}, draw.Src)
+
+		label := panels[i].Label
+		drawText(dst, label, x+(w-textWidth(label, scale))/2, gap+height+(band-glyphHeight*scale)/2, scale)
+		x += w + gap
+	}
+
+	return dst
+}
+
+// drawText draws text with the built-in font, its top left corner at (x, y)
+// and every font pixel scale pixels wide. Characters missing from the font
+// are left blank.
+func drawText(dst *image.RGBA, text string, x, y, scale int) {
+	for _, r := range text {
+		rows := glyphs[r]
+		for row, bits := range rows {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(dst, px, image.NewUniform(foreground), image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+// textWidth returns how wide drawText draws text
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+1) - 1) * scale
+}
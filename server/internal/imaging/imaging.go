@@ -0,0 +1,170 @@
+// Package imaging prepares uploaded photos for storage. Photos are decoded,
+// turned upright, scaled down and encoded again as JPEG, which also drops
+// every bit of metadata such as EXIF and GPS tags. It also lays stored photos
+// out side by side for comparisons.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png" // PNG uploads are accepted
+	"math"
+	"net/http"
+)
+
+const (
+	// MaxPixels bounds the decoded size of an upload, so a small file can't
+	// expand into gigabytes of pixels
+	MaxPixels = 40_000_000
+	// PhotoSize is the longest side of a stored photo, in pixels
+	PhotoSize = 2048
+	// ThumbnailSize is the longest side of a thumbnail, in pixels
+	ThumbnailSize = 320
+	// ContentType is the type of every image this package produces
+	ContentType = "image/jpeg"
+
+	jpegQuality = 85
+)
+
+var (
+	// ErrUnsupported is returned for anything but JPEG and PNG images
+	ErrUnsupported = errors.New("unsupported image format")
+	// ErrTooLarge is returned for images with more than MaxPixels pixels
+	ErrTooLarge = errors.New("image is too large")
+)
+
+// Photo is an upload ready to be stored
+type Photo struct {
+	Image     []byte
+	Thumbnail []byte
+	Width     int
+	Height    int
+}
+
+// Sniff returns the content type of data as detected from its first bytes,
+// regardless of what the client claimed, and rejects unsupported types
+func Sniff(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/jpeg", "image/png":
+		return contentType, nil
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// Prepare decodes an uploaded photo and produces the stored photo and its
+// thumbnail
+func Prepare(data []byte) (*Photo, error) {
+	img, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	full := Fit(img, PhotoSize)
+	photo, err := Encode(full)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail, err := Encode(Fit(full, ThumbnailSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Photo{
+		Image:     photo,
+		Thumbnail: thumbnail,
+		Width:     full.Bounds().Dx(),
+		Height:    full.Bounds().Dy(),
+	}, nil
+}
+
+// Decode reads a JPEG or PNG image into an opaque RGBA image, turned upright
+// according to its EXIF orientation. Transparent areas become white.
+func Decode(data []byte) (*image.RGBA, error) {
+	if _, err := Sniff(data); err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds(), src, src.Bounds().Min, draw.Over)
+
+	return orient(img, exifOrientation(data)), nil
+}
+
+// Encode writes img as a JPEG
+func Encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Fit scales img down, keeping its aspect ratio, until neither side is longer
+// than size. Each target pixel averages the source pixels it covers. Images
+// that already fit are returned as they are.
+func Fit(img *image.RGBA, size int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= size && h <= size {
+		return img
+	}
+
+	scale := float64(size) / float64(max(w, h))
+	return Resize(img, max(int(math.Round(float64(w)*scale)), 1), max(int(math.Round(float64(h)*scale)), 1))
+}
+
+// Resize scales img down to exactly width by height pixels
+func Resize(img *image.RGBA, width, height int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	origin := img.Bounds().Min
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := 0; dy < height; dy++ {
+		sy0 := dy * h / height
+		sy1 := max((dy+1)*h/height, sy0+1)
+		for dx := 0; dx < width; dx++ {
+			sx0 := dx * w / width
+			sx1 := max((dx+1)*w/width, sx0+1)
+
+			var r, g, b, a, n int
+			for sy := sy0; sy < sy1; sy++ {
+				i := img.PixOffset(origin.X+sx0, origin.Y+sy)
+				for sx := sx0; sx < sx1; sx++ {
+					r += int(img.Pix[i])
+					g += int(img.Pix[i+1])
+					b += int(img.Pix[i+2])
+					a += int(img.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
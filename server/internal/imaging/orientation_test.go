@@ -0,0 +1,216 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{R: 0xff, A: 0xff}
+	green = color.RGBA{G: 0xff, A: 0xff}
+	blue  = color.RGBA{B: 0xff, A: 0xff}
+	white = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+// quadrants returns a w by h image that is red at the top left, green at
+// the top right, blue at the bottom left and white at the bottom right
+func quadrants(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, image.Rect(0, 0, w/2, h/2), image.NewUniform(red), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(w/2, 0, w, h/2), image.NewUniform(green), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, h/2, w/2, h), image.NewUniform(blue), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(w/2, h/2, w, h), image.NewUniform(white), image.Point{}, draw.Src)
+	return img
+}
+
+// withOrientation encodes img as a JPEG carrying an EXIF orientation tag,
+// its TIFF structure in the given byte order
+func withOrientation(t *testing.T, img image.Image, orientation int, order binary.ByteOrder) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// TIFF header, one IFD with the orientation entry, no next IFD
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], exifOrientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], uint16(orientation))
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(payload)+2))
+	app1 = append(app1, payload...)
+
+	// The APP1 segment goes right after the start of image marker
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+// near reports whether c is within JPEG compression noise of want
+func near(c color.RGBA, want color.RGBA) bool {
+	diff := func(a, b uint8) int {
+		if a > b {
+			return int(a - b)
+		}
+		return int(b - a)
+	}
+	return diff(c.R, want.R) < 40 && diff(c.G, want.G) < 40 && diff(c.B, want.B) < 40
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	const w, h = 64, 32
+
+	// The corners of the upright image: top left, top right, bottom left and
+	// bottom right
+	tests := []struct {
+		orientation int
+		width       int
+		height      int
+		corners     [4]color.RGBA
+	}{
+		{1, w, h, [4]color.RGBA{red, green, blue, white}},
+		{2, w, h, [4]color.RGBA{green, red, white, blue}},
+		{3, w, h, [4]color.RGBA{white, blue, green, red}},
+		{4, w, h, [4]color.RGBA{blue, white, red, green}},
+		{5, h, w, [4]color.RGBA{red, blue, green, white}},
+		{6, h, w, [4]color.RGBA{blue, red, white, green}},
+		{7, h, w, [4]color.RGBA{white, green, blue, red}},
+		{8, h, w, [4]color.RGBA{green, white, red, blue}},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d", order, tt.orientation), func(t *testing.T) {
+				data := withOrientation(t, quadrants(w, h), tt.orientation, order)
+				if got := exifOrientation(data); got != tt.orientation {
+					t.Fatalf("exifOrientation = %d, want %d", got, tt.orientation)
+				}
+
+				img, err := Decode(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+					t.Fatalf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.width, tt.height)
+				}
+
+				// Sample a few pixels in from each corner, away from block edges
+				points := [4]image.Point{
+					{4, 4}, {tt.width - 5, 4}, {4, tt.height - 5}, {tt.width - 5, tt.height - 5},
+				}
+				for i, p := range points {
+					if c := img.RGBAAt(p.X, p.Y); !near(c, tt.corners[i]) {
+						t.Errorf("pixel at %v = %v, want %v", p, c, tt.corners[i])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestExifOrientationFallsBackToUpright(t *testing.T) {
+	var plain bytes.Buffer
+	if err := jpeg.Encode(&plain, quadrants(8, 8), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n")},
+		{"JPEG without EXIF", plain.Bytes()},
+		{"orientation out of range", withOrientation(t, quadrants(8, 8), 9, binary.LittleEndian)},
+		{"truncated segment", withOrientation(t, quadrants(8, 8), 6, binary.BigEndian)[:12]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != orientationNormal {
+				t.Errorf("exifOrientation = %d, want %d", got, orientationNormal)
+			}
+		})
+	}
+}
+
+func TestPrepareStripsMetadata(t *testing.T) {
+	data := withOrientation(t, quadrants(64, 32), 6, binary.BigEndian)
+
+	photo, err := Prepare(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if photo.Width != 32 || photo.Height != 64 {
+		t.Errorf("size = %dx%d, want 32x64", photo.Width, photo.Height)
+	}
+	for name, out := range map[string][]byte{"photo": photo.Image, "thumbnail": photo.Thumbnail} {
+		if bytes.Contains(out, []byte("Exif\x00\x00")) {
+			t.Errorf("%s still carries EXIF data", name)
+		}
+		if got := exifOrientation(out); got != orientationNormal {
+			t.Errorf("%s orientation = %d, want %d", name, got, orientationNormal)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		size          int
+		wantW, wantH  int
+	}{
+		{"already fits", 300, 200, 320, 300, 200},
+		{"landscape", 4000, 3000, 320, 320, 240},
+		{"portrait", 3000, 4000, 320, 240, 320},
+		{"square", 1000, 1000, 320, 320, 320},
+		{"very wide", 5000, 4, 320, 320, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := Fit(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.size)
+			if b := img.Bounds(); b.Dx() != tt.wantW || b.Dy() != tt.wantH {
+				t.Errorf("size = %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestResizeAverages(t *testing.T) {
+	img := quadrants(4, 4)
+
+	small := Resize(img, 2, 2)
+	want := [4]color.RGBA{red, green, blue, white}
+	for i, p := range []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+		if c := small.RGBAAt(p.X, p.Y); c != want[i] {
+			t.Errorf("pixel at %v = %v, want %v", p, c, want[i])
+		}
+	}
+
+	one := Resize(img, 1, 1).RGBAAt(0, 0)
+	if want := (color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}); one != want {
+		t.Errorf("single pixel = %v, want the average %v", one, want)
+	}
+}
@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrUsage is returned by Run for malformed migrate subcommands
+var ErrUsage = errors.New("usage: api migrate up [N] | down [N] | status | force VERSION")
+
+// Run executes a migrate subcommand, e.g. the arguments after "api migrate"
+func (m *Migrator) Run(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	// up and down take an optional step count, force a required version
+	number := 0
+	if len(args) > 2 {
+		return ErrUsage
+	}
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return ErrUsage
+		}
+		number = n
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx, number)
+	case "down":
+		return m.Down(ctx, number)
+	case "force":
+		if len(args) != 2 {
+			return ErrUsage
+		}
+		return m.Force(ctx, number)
+	case "status":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(out, "%03d_%-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+	default:
+		return ErrUsage
+	}
+}
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/hari4698/hardinfinity/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"002_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"002_add_index.down.sql":    {Data: []byte("DROP INDEX i;")},
+		"001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"003_no_down.up.sql":        {Data: []byte("ALTER TABLE t ADD d INT;")},
+		"README.md":                 {Data: []byte("not a migration")},
+	}
+
+	got, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE t (c INT);", Down: "DROP TABLE t;"},
+		{Version: 2, Name: "add_index", Up: "CREATE INDEX i ON t (c);", Down: "DROP INDEX i;"},
+		{Version: 3, Name: "no_down", Up: "ALTER TABLE t ADD d INT;"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("loaded %d migrations, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("migration %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLoadRejectsMalformedFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"unnumbered file", fstest.MapFS{"create_table.up.sql": {}}},
+		{"neither up nor down", fstest.MapFS{"001_create_table.sql": {}}},
+		{"conflicting names", fstest.MapFS{
+			"001_create_table.up.sql":    {Data: []byte("CREATE TABLE t (c INT);")},
+			"001_create_tables.down.sql": {Data: []byte("DROP TABLE t;")},
+		}},
+		{"down without up", fstest.MapFS{"001_create_table.down.sql": {Data: []byte("DROP TABLE t;")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(tt.fsys); err == nil {
+				t.Error("load succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := load(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range loaded {
+		if m.Version != i+1 {
+			t.Errorf("migration %d_%s follows version %d, want consecutive versions", m.Version, m.Name, i)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
@@ -2,30 +2,36 @@ package models
 
 import (
 	"time"
-	
+
 	"github.com/google/uuid"
 )
 
 type User struct {
 	ID        uuid.UUID `json:"id"`
-		ClerkID   string    `json:"clerk_id"`
-		Email     string    `json:"email"`
-		Name      string    `json:"name"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+	ClerkID   string    `json:"clerk_id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Timezone  string    `json:"timezone"` // IANA name, UTC by default
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type Challenge struct {
-	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	StartDate   time.Time `json:"start_date"`
-	EndDate     time.Time `json:"end_date"`
-	CurrentDay  int       `json:"current_day"`
-	Status      string    `json:"status"` // active, completed, failed
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                uuid.UUID `json:"id"`
+	UserID            uuid.UUID `json:"user_id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	StartDate         time.Time `json:"start_date"`
+	EndDate           time.Time `json:"end_date"`
+	DurationDays      int       `json:"duration_days"`
+	CurrentDay        int       `json:"current_day"`
+	Status            string    `json:"status"`               // active, completed, failed
+	Timezone          string    `json:"timezone"`             // overrides the user's timezone when set
+	EffectiveTimezone string    `json:"effective_timezone"`   // the override or the user's timezone
+	LocalDate         string    `json:"local_date,omitempty"` // today in EffectiveTimezone
+	GraceHours        *int      `json:"grace_hours"`          // how long past days stay editable; see rules.Grace
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 type Section struct {
@@ -39,56 +45,191 @@ type Section struct {
 }
 
 type Task struct {
-	ID            uuid.UUID `json:"id"`
-	SectionID     uuid.UUID `json:"section_id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	TaskType      string    `json:"task_type"` // boolean, number, text, select, etc.
-	Required      bool      `json:"required"`
-	RestartOnFail bool      `json:"restart_on_fail"`
-	StrikesEnabled bool     `json:"strikes_enabled"`
-	StrikesLimit   int      `json:"strikes_limit"`
-	Order         int       `json:"order"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uuid.UUID    `json:"id"`
+	SectionID      uuid.UUID    `json:"section_id"`
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	TaskType       string       `json:"task_type"` // see the tasktypes package
+	Required       bool         `json:"required"`
+	RestartOnFail  bool         `json:"restart_on_fail"`
+	StrikesEnabled bool         `json:"strikes_enabled"`
+	StrikesLimit   int          `json:"strikes_limit"`
+	Config         TaskConfig   `json:"config"`
+	Schedule       TaskSchedule `json:"schedule"`
+	Order          int          `json:"order"`
+	Revision       int          `json:"revision"`    // bumped by every edit of the task
+	ArchivedAt     *time.Time   `json:"archived_at"` // set once the task is deleted
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// TaskConfig holds the settings that apply to a task's type. Fields that do
+// not apply to the type are left empty.
+type TaskConfig struct {
+	// Min and Max bound numeric values; for ratings they are the scale
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Unit labels numeric values, e.g. "ml"; durations use seconds, minutes
+	// or hours
+	Unit string `json:"unit,omitempty"`
+	// MaxLength limits text values, in characters
+	MaxLength int `json:"max_length,omitempty"`
+	// Options lists the allowed select values
+	Options []string `json:"options,omitempty"`
+	// Labels names the sub-fields of a multi-number task
+	Labels []string `json:"labels,omitempty"`
+	// Target marks an entry completed when its value meets it
+	Target *TaskTarget `json:"target,omitempty"`
+	// Default is the value recorded when an entry leaves it out
+	Default any `json:"default,omitempty"`
+}
+
+// TaskTarget is the value a task entry has to reach to count as completed
+type TaskTarget struct {
+	// Comparator is one of gte, gt, lte, lt or eq. Boolean and select
+	// targets only support eq.
+	Comparator string `json:"comparator"`
+	// Value is a number for numeric types, where a multi-number task
+	// compares the sum of its fields, or a valid value of the task otherwise
+	Value any `json:"value"`
+}
+
+// TaskSchedule says on which days of a challenge a task is due
+type TaskSchedule struct {
+	// Kind is daily, weekdays, every_n_days, times_per_week or days. An
+	// empty kind means daily.
+	Kind string `json:"kind"`
+	// Weekdays lists the due weekdays as sun, mon, ... sat
+	Weekdays []string `json:"weekdays,omitempty"`
+	// Every is the gap between due days, counting from day 1
+	Every int `json:"every,omitempty"`
+	// TimesPerWeek is how often the task must be done in each seven-day
+	// week of the challenge, on any days
+	TimesPerWeek int `json:"times_per_week,omitempty"`
+	// Days lists the due day numbers
+	Days []int `json:"days,omitempty"`
 }
 
 type DailyEntry struct {
-	ID              uuid.UUID `json:"id"`
-	ChallengeID     uuid.UUID `json:"challenge_id"`
-	DayNumber       int       `json:"day_number"`
-	Date            time.Time `json:"date"`
-	Completed       bool      `json:"completed"`
-	Notes           string    `json:"notes"`
-	ProgressPhotoURL string   `json:"progress_photo_url"`
-	EnergyLevel     int       `json:"energy_level"`
-	MoodLevel       int       `json:"mood_level"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID                   uuid.UUID    `json:"id"`
+	ChallengeID          uuid.UUID    `json:"challenge_id"`
+	AttemptID            uuid.UUID    `json:"attempt_id"`
+	DayNumber            int          `json:"day_number"`
+	Date                 time.Time    `json:"date"`
+	Completed            bool         `json:"completed"`
+	Notes                string       `json:"notes"`
+	ProgressPhotoURL     string       `json:"progress_photo_url"` // a signed URL of the first progress photo attachment, if any
+	ProgressThumbnailURL string       `json:"progress_thumbnail_url,omitempty"`
+	PhotoKey             string       `json:"-"` // blob key of the first progress photo attachment
+	ThumbnailKey         string       `json:"-"` // blob key of its thumbnail
+	EnergyLevel          int          `json:"energy_level"`
+	MoodLevel            int          `json:"mood_level"`
+	Backfilled           bool         `json:"backfilled"`            // first logged after the day ended
+	EditedAt             *time.Time   `json:"edited_at"`             // last change made after the day ended
+	Attachments          []Attachment `json:"attachments,omitempty"` // in order, when loaded
+	CreatedAt            time.Time    `json:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at"`
+}
+
+// Attempt is one run at a challenge. Resetting a challenge closes the current
+// attempt and starts the next one, keeping the earlier entries.
+type Attempt struct {
+	ID            uuid.UUID  `json:"id"`
+	ChallengeID   uuid.UUID  `json:"challenge_id"`
+	AttemptNumber int        `json:"attempt_number"`
+	StartDate     time.Time  `json:"start_date"`
+	StartedAt     time.Time  `json:"started_at"`
+	EndedAt       *time.Time `json:"ended_at"`
+	EndReason     string     `json:"end_reason"`
+	DayReached    int        `json:"day_reached"`
+	EntriesLogged int        `json:"entries_logged"`
+	CompletedDays int        `json:"completed_days"`
 }
 
 type TaskEntry struct {
 	ID           uuid.UUID    `json:"id"`
 	DailyEntryID uuid.UUID    `json:"daily_entry_id"`
 	TaskID       uuid.UUID    `json:"task_id"`
+	TaskRevision int          `json:"task_revision"` // the revision of the task it was recorded against
 	Completed    bool         `json:"completed"`
 	Value        interface{}  `json:"value"` // This will be handled as JSON
 	Notes        string       `json:"notes"`
+	Task         *Task        `json:"task,omitempty"`        // the task as it was at TaskRevision, when loaded
+	Attachments  []Attachment `json:"attachments,omitempty"` // in order, when loaded
 	CreatedAt    time.Time    `json:"created_at"`
 	UpdatedAt    time.Time    `json:"updated_at"`
 }
 
+// Attachment is a file attached to a daily entry or to one of its task
+// entries; exactly one of DailyEntryID and TaskEntryID is set. The file is
+// only reachable through the signed URLs, which expire.
+type Attachment struct {
+	ID           uuid.UUID  `json:"id"`
+	DailyEntryID *uuid.UUID `json:"daily_entry_id"`
+	TaskEntryID  *uuid.UUID `json:"task_entry_id"`
+	TaskID       *uuid.UUID `json:"task_id"` // the task of TaskEntryID
+	ChallengeID  uuid.UUID  `json:"challenge_id"`
+	AttemptID    uuid.UUID  `json:"attempt_id"`
+	DayNumber    int        `json:"day_number"`
+	Date         time.Time  `json:"date"`
+	Kind         string     `json:"kind"` // progress_photo, photo or screenshot
+	MimeType     string     `json:"mime_type"`
+	Size         int64      `json:"size"` // in bytes
+	Order        int        `json:"order"`
+	Caption      string     `json:"caption"`
+	URL          string     `json:"url"`
+	ThumbnailURL string     `json:"thumbnail_url,omitempty"`
+	BlobKey      string     `json:"-"`
+	ThumbnailKey string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Measurement is one set of body measurements. Values that were not measured
+// are nil.
 type Measurement struct {
 	ID          uuid.UUID `json:"id"`
 	ChallengeID uuid.UUID `json:"challenge_id"`
 	DayNumber   int       `json:"day_number"`
 	Date        time.Time `json:"date"`
-	Weight      float64   `json:"weight"`
-	Chest       float64   `json:"chest"`
-	Waist       float64   `json:"waist"`
-	Hips        float64   `json:"hips"`
-	Arms        float64   `json:"arms"`
-	Thighs      float64   `json:"thighs"`
+	Weight      *float64  `json:"weight"`
+	Chest       *float64  `json:"chest"`
+	Waist       *float64  `json:"waist"`
+	Hips        *float64  `json:"hips"`
+	Arms        *float64  `json:"arms"`
+	Thighs      *float64  `json:"thighs"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+// Template is a reusable challenge layout. Built-in templates ship with the
+// API and have no owner; the rest are private to the user who saved them.
+type Template struct {
+	ID           uuid.UUID         `json:"id"`
+	UserID       *uuid.UUID        `json:"user_id,omitempty"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	DurationDays int               `json:"duration_days"`
+	Builtin      bool              `json:"builtin"`
+	Sections     []TemplateSection `json:"sections"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+type TemplateSection struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Tasks       []TemplateTask `json:"tasks"`
+}
+
+type TemplateTask struct {
+	Name           string       `json:"name"`
+	Description    string       `json:"description"`
+	TaskType       string       `json:"task_type"`
+	Required       bool         `json:"required"`
+	RestartOnFail  bool         `json:"restart_on_fail"`
+	StrikesEnabled bool         `json:"strikes_enabled"`
+	StrikesLimit   int          `json:"strikes_limit"`
+	Config         TaskConfig   `json:"config"`
+	Schedule       TaskSchedule `json:"schedule"`
+}
@@ -0,0 +1,177 @@
+// Package rollover closes the days of active challenges once they are over,
+// so a challenge keeps moving even when nobody opens the app.
+package rollover
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/rules"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// DefaultInterval is how often the scheduler looks for days that ended. Days
+// end at midnight in each challenge's timezone, so a challenge rolls over at
+// most one interval after its local midnight.
+const DefaultInterval = time.Minute
+
+// Scheduler periodically rolls over every challenge whose current day is in
+// the past. Each challenge is claimed with a row lock that other replicas
+// skip, so any number of schedulers can run against the same database.
+type Scheduler struct {
+	store    store.Store
+	interval time.Duration
+	now      func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a scheduler that checks for due challenges every interval
+func New(s store.Store, interval time.Duration) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Scheduler{store: s, interval: interval, now: time.Now}
+}
+
+// Start runs the scheduler in the background until Stop is called
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Day rollover failed: %v", err)
+			} else if n > 0 {
+				log.Printf("Rolled over %d challenges", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the running pass and waits for the scheduler to exit or for
+// ctx to expire
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunOnce rolls over every challenge that is due and reports how many it
+// handled. A challenge that fails is logged and left for the next pass, as is
+// one whose final day is over but still within its grace window.
+func (s *Scheduler) RunOnce(ctx context.Context) (int, error) {
+	var skip []uuid.UUID
+	rolled := 0
+
+	for ctx.Err() == nil {
+		var claimed *models.Challenge
+		var waiting bool
+		err := s.store.WithTx(ctx, func(tx store.Store) error {
+			var err error
+			claimed, err = tx.ClaimDueChallenge(ctx, skip)
+			if err != nil {
+				return err
+			}
+			waiting, err = s.rollover(ctx, tx, claimed)
+			return err
+		})
+
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return rolled, nil
+		case err != nil && claimed == nil:
+			return rolled, err
+		case err != nil:
+			log.Printf("Failed to roll over challenge %s: %v", claimed.ID, err)
+			skip = append(skip, claimed.ID)
+		case waiting:
+			skip = append(skip, claimed.ID)
+		default:
+			rolled++
+		}
+	}
+
+	return rolled, ctx.Err()
+}
+
+// rollover closes every day of c that is over. Days without an entry are
+// recorded as incomplete, then the fail and strike rules run over the closed
+// days that are past their grace window. If the challenge survives it moves
+// to today's day number. Once its final day is over it stays on that day
+// through the grace window, like any other day, and is then completed: by
+// then every day is settled and none of them broke a rule. It reports whether
+// c is still waiting for its final day to lock.
+func (s *Scheduler) rollover(ctx context.Context, tx store.Store, c *models.Challenge) (bool, error) {
+	now := s.now()
+	today := dates.Of(now, dates.Location(c.EffectiveTimezone))
+	expected := schedule.CalendarOf(c).Day(today)
+	lastClosed := min(expected-1, c.DurationDays)
+
+	for day := c.CurrentDay; day <= lastClosed; day++ {
+		_, err := tx.GetEntry(ctx, c.ID, day)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return false, err
+		}
+
+		missed := &models.DailyEntry{
+			ChallengeID: c.ID,
+			DayNumber:   day,
+			Date:        c.StartDate.AddDate(0, 0, day-1),
+		}
+		if err := tx.CreateEntry(ctx, missed); err != nil {
+			return false, err
+		}
+	}
+
+	closed := *c
+	closed.CurrentDay = lastClosed + 1
+	verdict, err := rules.Enforce(ctx, tx, &closed, now)
+	if err != nil || verdict.Action != rules.ActionNone {
+		return false, err
+	}
+
+	if expected <= c.DurationDays {
+		return false, tx.SetChallengeDay(ctx, c.ID, expected)
+	}
+
+	if err := tx.SetChallengeDay(ctx, c.ID, c.DurationDays); err != nil {
+		return false, err
+	}
+	if !rules.Locked(c, c.DurationDays, now) {
+		return true, nil
+	}
+	return false, tx.SetChallengeStatus(ctx, c.ID, "completed")
+}
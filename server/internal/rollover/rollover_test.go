@@ -0,0 +1,241 @@
+package rollover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// fakeStore holds a single challenge in memory. Only the methods the
+// rollover uses are implemented; the embedded Store panics on the rest.
+type fakeStore struct {
+	store.Store
+
+	now         func() time.Time
+	challenge   models.Challenge
+	tasks       []models.Task
+	entries     map[int]*models.DailyEntry
+	taskEntries []models.TaskEntry
+	claims      int
+	resets      int
+}
+
+func (f *fakeStore) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	return fn(f)
+}
+
+func (f *fakeStore) ClaimDueChallenge(ctx context.Context, skip []uuid.UUID) (*models.Challenge, error) {
+	c := f.challenge
+	for _, id := range skip {
+		if id == c.ID {
+			return nil, store.ErrNotFound
+		}
+	}
+	today := time.Date(f.now().Year(), f.now().Month(), f.now().Day(), 0, 0, 0, 0, time.UTC)
+	if c.Status != "active" || !c.StartDate.AddDate(0, 0, c.CurrentDay-1).Before(today) {
+		return nil, store.ErrNotFound
+	}
+	f.claims++
+	return &c, nil
+}
+
+func (f *fakeStore) GetEntry(ctx context.Context, challengeID uuid.UUID, dayNumber int) (*models.DailyEntry, error) {
+	entry, ok := f.entries[dayNumber]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+func (f *fakeStore) CreateEntry(ctx context.Context, entry *models.DailyEntry) error {
+	entry.ID = uuid.New()
+	copied := *entry
+	f.entries[entry.DayNumber] = &copied
+	return nil
+}
+
+func (f *fakeStore) ListEntries(ctx context.Context, challengeID uuid.UUID) ([]models.DailyEntry, error) {
+	var entries []models.DailyEntry
+	for _, entry := range f.entries {
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+func (f *fakeStore) ListAttemptTaskEntries(ctx context.Context, challengeID uuid.UUID) ([]models.TaskEntry, error) {
+	return f.taskEntries, nil
+}
+
+func (f *fakeStore) ListChallengeTasks(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error) {
+	return f.tasks, nil
+}
+
+func (f *fakeStore) ListTaskRevisions(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error) {
+	return f.tasks, nil
+}
+
+func (f *fakeStore) SetChallengeDay(ctx context.Context, challengeID uuid.UUID, day int) error {
+	f.challenge.CurrentDay = day
+	return nil
+}
+
+func (f *fakeStore) SetChallengeStatus(ctx context.Context, challengeID uuid.UUID, status string) error {
+	f.challenge.Status = status
+	return nil
+}
+
+func (f *fakeStore) ResetChallenge(ctx context.Context, userID, challengeID uuid.UUID, reason string, startDate time.Time) error {
+	f.resets++
+	f.challenge.StartDate, f.challenge.CurrentDay = startDate, 1
+	f.entries, f.taskEntries = map[int]*models.DailyEntry{}, nil
+	return nil
+}
+
+// complete records a completed entry for each task on day
+func (f *fakeStore) complete(day int, tasks ...models.Task) {
+	entry := &models.DailyEntry{ID: uuid.New(), DayNumber: day, Completed: true}
+	f.entries[day] = entry
+	for _, task := range tasks {
+		f.taskEntries = append(f.taskEntries, models.TaskEntry{
+			ID: uuid.New(), DailyEntryID: entry.ID, TaskID: task.ID, TaskRevision: task.Revision, Completed: true,
+		})
+	}
+}
+
+// newFake returns a UTC challenge of length days that started on
+// 2025-03-03, with the scheduler's clock at clock
+func newFake(length, currentDay int, clock *time.Time, tasks ...models.Task) (*fakeStore, *Scheduler) {
+	f := &fakeStore{
+		now: func() time.Time { return *clock },
+		challenge: models.Challenge{
+			ID:                uuid.New(),
+			UserID:            uuid.New(),
+			StartDate:         time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			DurationDays:      length,
+			CurrentDay:        currentDay,
+			Status:            "active",
+			EffectiveTimezone: "UTC",
+		},
+		tasks:   tasks,
+		entries: map[int]*models.DailyEntry{},
+	}
+	s := New(f, time.Minute)
+	s.now = f.now
+	return f, s
+}
+
+func TestRolloverMidChallenge(t *testing.T) {
+	task := models.Task{ID: uuid.New(), Name: "Read", Required: true, Revision: 1}
+	// Day 5 of the challenge, with days 3 and 4 never opened
+	clock := time.Date(2025, 3, 7, 9, 0, 0, 0, time.UTC)
+	f, s := newFake(10, 3, &clock, task)
+	f.complete(1, task)
+	f.complete(2, task)
+
+	n, err := s.RunOnce(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v, want 1, nil", n, err)
+	}
+	if f.challenge.CurrentDay != 5 || f.challenge.Status != "active" {
+		t.Errorf("challenge on day %d, %s, want day 5, active", f.challenge.CurrentDay, f.challenge.Status)
+	}
+	for _, day := range []int{3, 4} {
+		if entry, ok := f.entries[day]; !ok || entry.Completed {
+			t.Errorf("day %d = %+v, want an incomplete entry", day, entry)
+		}
+	}
+	if _, ok := f.entries[5]; ok {
+		t.Error("today got an entry before it ended")
+	}
+}
+
+func TestRolloverFinalDay(t *testing.T) {
+	ctx := context.Background()
+	required := models.Task{ID: uuid.New(), Name: "Read", Required: true, Revision: 1}
+	restart := models.Task{ID: uuid.New(), Name: "Workout", Required: true, RestartOnFail: true, Revision: 1}
+
+	// The final day, day 5, ends at midnight on 2025-03-08 and locks a day
+	// later with the default grace window
+	ended := time.Date(2025, 3, 8, 0, 1, 0, 0, time.UTC)
+	locked := time.Date(2025, 3, 9, 0, 1, 0, 0, time.UTC)
+
+	t.Run("stays active through the grace window", func(t *testing.T) {
+		clock := ended
+		f, s := newFake(5, 5, &clock, restart)
+		for day := 1; day <= 4; day++ {
+			f.complete(day, restart)
+		}
+
+		n, err := s.RunOnce(ctx)
+		if err != nil || n != 0 {
+			t.Fatalf("RunOnce = %d, %v, want 0, nil", n, err)
+		}
+		if f.claims != 1 {
+			t.Errorf("claimed %d times in one pass, want 1", f.claims)
+		}
+		if f.challenge.Status != "active" || f.challenge.CurrentDay != 5 || f.resets != 0 {
+			t.Fatalf("challenge %s on day %d after %d resets, want active on day 5", f.challenge.Status, f.challenge.CurrentDay, f.resets)
+		}
+
+		// The final day is backfilled within the grace window
+		f.complete(5, restart)
+		clock = locked
+		if n, err := s.RunOnce(ctx); err != nil || n != 1 {
+			t.Fatalf("RunOnce after the lock = %d, %v, want 1, nil", n, err)
+		}
+		if f.challenge.Status != "completed" {
+			t.Errorf("status = %s, want completed", f.challenge.Status)
+		}
+	})
+
+	t.Run("missed restart-on-fail task resets once locked", func(t *testing.T) {
+		clock := locked
+		f, s := newFake(5, 5, &clock, restart)
+		for day := 1; day <= 4; day++ {
+			f.complete(day, restart)
+		}
+
+		if _, err := s.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if f.resets != 1 || f.challenge.Status != "active" {
+			t.Errorf("challenge %s after %d resets, want active after 1", f.challenge.Status, f.resets)
+		}
+	})
+
+	t.Run("completed when no rule is broken", func(t *testing.T) {
+		// Read is required but has no fail rule, so an incomplete day does
+		// not fail the challenge
+		clock := locked
+		f, s := newFake(5, 3, &clock, required)
+		f.complete(1, required)
+
+		if _, err := s.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if f.challenge.Status != "completed" || f.challenge.CurrentDay != 5 {
+			t.Errorf("challenge %s on day %d, want completed on day 5", f.challenge.Status, f.challenge.CurrentDay)
+		}
+	})
+
+	t.Run("strike limit exceeded fails", func(t *testing.T) {
+		strikes := models.Task{ID: uuid.New(), Name: "Water", Required: true, StrikesEnabled: true, StrikesLimit: 1, Revision: 1}
+		clock := locked
+		f, s := newFake(5, 5, &clock, strikes)
+		for day := 1; day <= 3; day++ {
+			f.complete(day, strikes)
+		}
+
+		if _, err := s.RunOnce(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if f.challenge.Status != "failed" {
+			t.Errorf("status = %s, want failed", f.challenge.Status)
+		}
+	})
+}
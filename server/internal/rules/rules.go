@@ -0,0 +1,217 @@
+// Package rules enforces the per-task failure rules of a challenge: required
+// tasks that restart the challenge when missed, and strike limits.
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/store"
+)
+
+// Action is what the challenge has to do after an evaluation
+type Action string
+
+const (
+	// ActionNone lets the challenge carry on
+	ActionNone Action = "none"
+	// ActionFail marks the challenge failed
+	ActionFail Action = "fail"
+	// ActionReset ends the current attempt and restarts at day 1
+	ActionReset Action = "reset"
+)
+
+// TaskStrikes is the strike count of one task across the current attempt
+type TaskStrikes struct {
+	TaskID           uuid.UUID `json:"task_id"`
+	TaskName         string    `json:"task_name"`
+	StrikesUsed      int       `json:"strikes_used"`
+	StrikesLimit     int       `json:"strikes_limit"`
+	StrikesRemaining int       `json:"strikes_remaining"`
+}
+
+// Verdict is the outcome of evaluating a challenge's current attempt
+type Verdict struct {
+	Action Action `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	// TriggeredBy is the task that failed the challenge, and DayNumber the
+	// day it was missed on
+	TriggeredBy *TaskStrikes `json:"triggered_by,omitempty"`
+	DayNumber   int          `json:"day_number,omitempty"`
+	// Strikes lists every task that has strikes enabled
+	Strikes []TaskStrikes `json:"strikes"`
+}
+
+// Day is one day of the current attempt as seen by the rules
+type Day struct {
+	Number int
+	// Settled days count towards the rules. A day is settled once the
+	// challenge moved past it and it is locked, or once its entry was marked
+	// completed; until then it can still be filled in.
+	Settled bool
+	// Tasks holds the tasks in effect that day, each as it was defined then
+	Tasks []models.Task
+	// Completed holds the tasks that were done that day
+	Completed map[uuid.UUID]bool
+}
+
+// Evaluate walks the settled days in order and returns the first rule that
+// was broken, along with the strike count of every strike-enabled task.
+// Missing a task with strikes enabled uses a strike, and going over the limit
+// fails the task. Otherwise missing a required restart-on-fail task fails it
+// straight away. A failed task resets the challenge when it is restart-on-fail
+// and fails the challenge when it is not. Each day is judged against the
+// tasks in effect on it, and a task only on the days its schedule makes it
+// due. Strike counts are reported for tasks, the tasks as they are now.
+func Evaluate(tasks []models.Task, cal schedule.Calendar, days []Day) Verdict {
+	verdict := Verdict{Action: ActionNone, Strikes: []TaskStrikes{}}
+
+	history := map[uuid.UUID]schedule.History{}
+	for _, day := range days {
+		for taskID := range day.Completed {
+			if history[taskID] == nil {
+				history[taskID] = schedule.History{}
+			}
+			history[taskID][day.Number] = true
+		}
+	}
+
+	used := make(map[uuid.UUID]int, len(tasks))
+	for _, day := range days {
+		if !day.Settled {
+			continue
+		}
+
+		for _, task := range day.Tasks {
+			if !schedule.Missed(task.Schedule, cal, day.Number, history[task.ID]) {
+				continue
+			}
+
+			switch {
+			case task.StrikesEnabled:
+				used[task.ID]++
+				if used[task.ID] <= task.StrikesLimit || verdict.Action != ActionNone {
+					continue
+				}
+				verdict.Reason = fmt.Sprintf("%s exceeded its limit of %d strikes", task.Name, task.StrikesLimit)
+			case task.Required && task.RestartOnFail:
+				if verdict.Action != ActionNone {
+					continue
+				}
+				used[task.ID]++
+				verdict.Reason = fmt.Sprintf("%s was missed", task.Name)
+			default:
+				continue
+			}
+
+			verdict.Action = ActionFail
+			if task.RestartOnFail {
+				verdict.Action = ActionReset
+			}
+			strikes := taskStrikes(task, used[task.ID])
+			verdict.TriggeredBy = &strikes
+			verdict.DayNumber = day.Number
+		}
+	}
+
+	for _, task := range tasks {
+		if task.StrikesEnabled {
+			verdict.Strikes = append(verdict.Strikes, taskStrikes(task, used[task.ID]))
+		}
+	}
+
+	return verdict
+}
+
+func taskStrikes(task models.Task, used int) TaskStrikes {
+	return TaskStrikes{
+		TaskID:           task.ID,
+		TaskName:         task.Name,
+		StrikesUsed:      used,
+		StrikesLimit:     task.StrikesLimit,
+		StrikesRemaining: max(task.StrikesLimit-used, 0),
+	}
+}
+
+// Enforce evaluates the current attempt of an active challenge as of now and
+// applies the verdict: the challenge is marked failed, or reset to start again
+// on today. Every settled day is judged again, so a backfilled day counts as
+// soon as it is saved. It must run inside the transaction that saved the
+// entries.
+func Enforce(ctx context.Context, tx store.Store, challenge *models.Challenge, now time.Time) (*Verdict, error) {
+	tasks, err := tx.ListChallengeTasks(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := tx.ListTaskRevisions(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := attemptDays(ctx, tx, challenge, schedule.NewTimeline(challenge, revisions), now)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := Evaluate(tasks, schedule.CalendarOf(challenge), days)
+	switch verdict.Action {
+	case ActionFail:
+		err = tx.SetChallengeStatus(ctx, challenge.ID, "failed")
+	case ActionReset:
+		today := dates.Of(now, dates.Location(challenge.EffectiveTimezone))
+		err = tx.ResetChallenge(ctx, challenge.UserID, challenge.ID, verdict.Reason, today)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &verdict, nil
+}
+
+// attemptDays loads the days of the current attempt with the tasks timeline
+// puts in effect on each. Days before the current day that have no entry were
+// skipped, so every task counts as missed once they are locked.
+func attemptDays(ctx context.Context, tx store.Store, challenge *models.Challenge, timeline *schedule.Timeline, now time.Time) ([]Day, error) {
+	entries, err := tx.ListEntries(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskEntries, err := tx.ListAttemptTaskEntries(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	last := challenge.CurrentDay - 1
+	for _, entry := range entries {
+		last = max(last, entry.DayNumber)
+	}
+
+	days := make([]Day, last)
+	byEntry := make(map[uuid.UUID]*Day, len(entries))
+	for i := range days {
+		settled := i+1 < challenge.CurrentDay && Locked(challenge, i+1, now)
+		days[i] = Day{Number: i + 1, Settled: settled, Tasks: timeline.On(i + 1), Completed: map[uuid.UUID]bool{}}
+	}
+	for _, entry := range entries {
+		if entry.DayNumber < 1 {
+			continue
+		}
+		day := &days[entry.DayNumber-1]
+		day.Settled = day.Settled || entry.Completed
+		byEntry[entry.ID] = day
+	}
+	for _, te := range taskEntries {
+		if day, ok := byEntry[te.DailyEntryID]; ok && te.Completed {
+			day.Completed[te.TaskID] = true
+		}
+	}
+
+	return days, nil
+}
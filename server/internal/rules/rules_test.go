@@ -0,0 +1,340 @@
+package rules
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the lock window cases load named timezones
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+)
+
+// monday is a 14 day calendar starting on Monday 2025-03-03
+var monday = schedule.Calendar{Start: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Length: 14}
+
+// settled returns n settled days, each with the given tasks completed
+func settled(n int, completed map[int][]uuid.UUID) []Day {
+	days := make([]Day, n)
+	for i := range days {
+		days[i] = Day{Number: i + 1, Settled: true, Completed: map[uuid.UUID]bool{}}
+		for _, id := range completed[i+1] {
+			days[i].Completed[id] = true
+		}
+	}
+	return days
+}
+
+func TestEvaluate(t *testing.T) {
+	restart := models.Task{ID: uuid.New(), Name: "Workout", Required: true, RestartOnFail: true}
+	required := models.Task{ID: uuid.New(), Name: "Read", Required: true}
+	optional := models.Task{ID: uuid.New(), Name: "Stretch"}
+	strikes := models.Task{ID: uuid.New(), Name: "Water", Required: true, StrikesEnabled: true, StrikesLimit: 2}
+	strikesRestart := models.Task{ID: uuid.New(), Name: "Diet", Required: true, RestartOnFail: true, StrikesEnabled: true, StrikesLimit: 1}
+	noStrikes := models.Task{ID: uuid.New(), Name: "Cold shower", StrikesEnabled: true}
+	weekdays := models.Task{ID: uuid.New(), Name: "Run", Required: true, RestartOnFail: true,
+		Schedule: models.TaskSchedule{Kind: schedule.Weekdays, Weekdays: []string{"mon", "wed", "fri"}}}
+	thrice := models.Task{ID: uuid.New(), Name: "Yoga", Required: true, RestartOnFail: true,
+		Schedule: models.TaskSchedule{Kind: schedule.TimesPerWeek, TimesPerWeek: 3}}
+
+	tests := []struct {
+		name      string
+		tasks     []models.Task
+		days      []Day
+		want      Action
+		day       int
+		triggered *models.Task
+		used      map[uuid.UUID]int
+	}{
+		{
+			name:  "no days",
+			tasks: []models.Task{restart, strikes},
+			want:  ActionNone,
+			used:  map[uuid.UUID]int{strikes.ID: 0},
+		},
+		{
+			name:  "everything done",
+			tasks: []models.Task{restart, strikes},
+			days:  settled(3, map[int][]uuid.UUID{1: {restart.ID, strikes.ID}, 2: {restart.ID, strikes.ID}, 3: {restart.ID, strikes.ID}}),
+			want:  ActionNone,
+			used:  map[uuid.UUID]int{strikes.ID: 0},
+		},
+		{
+			name:      "restart-on-fail task missed",
+			tasks:     []models.Task{restart},
+			days:      settled(3, map[int][]uuid.UUID{1: {restart.ID}, 2: {restart.ID}}),
+			want:      ActionReset,
+			day:       3,
+			triggered: &restart,
+		},
+		{
+			name:  "unsettled day is not judged",
+			tasks: []models.Task{restart},
+			days: func() []Day {
+				d := settled(3, map[int][]uuid.UUID{1: {restart.ID}, 2: {restart.ID}})
+				d[2].Settled = false
+				return d
+			}(),
+			want: ActionNone,
+		},
+		{
+			name:  "required task without restart or strikes never fails",
+			tasks: []models.Task{required},
+			days:  settled(5, nil),
+			want:  ActionNone,
+		},
+		{
+			name:  "optional task never fails",
+			tasks: []models.Task{optional},
+			days:  settled(5, nil),
+			want:  ActionNone,
+		},
+		{
+			name:  "strikes within the limit",
+			tasks: []models.Task{strikes},
+			days:  settled(4, map[int][]uuid.UUID{1: {strikes.ID}, 3: {strikes.ID}}),
+			want:  ActionNone,
+			used:  map[uuid.UUID]int{strikes.ID: 2},
+		},
+		{
+			name:      "strikes over the limit fail the challenge",
+			tasks:     []models.Task{strikes},
+			days:      settled(4, map[int][]uuid.UUID{1: {strikes.ID}}),
+			want:      ActionFail,
+			day:       4,
+			triggered: &strikes,
+			used:      map[uuid.UUID]int{strikes.ID: 3},
+		},
+		{
+			name:      "strikes keep counting after the limit",
+			tasks:     []models.Task{strikes},
+			days:      settled(6, nil),
+			want:      ActionFail,
+			day:       3,
+			triggered: &strikes,
+			used:      map[uuid.UUID]int{strikes.ID: 6},
+		},
+		{
+			name:      "strikes over the limit of a restart-on-fail task reset",
+			tasks:     []models.Task{strikesRestart},
+			days:      settled(3, map[int][]uuid.UUID{2: {strikesRestart.ID}}),
+			want:      ActionReset,
+			day:       3,
+			triggered: &strikesRestart,
+			used:      map[uuid.UUID]int{strikesRestart.ID: 2},
+		},
+		{
+			name:      "strike limit of zero fails on the first miss",
+			tasks:     []models.Task{noStrikes},
+			days:      settled(2, map[int][]uuid.UUID{1: {noStrikes.ID}}),
+			want:      ActionFail,
+			day:       2,
+			triggered: &noStrikes,
+			used:      map[uuid.UUID]int{noStrikes.ID: 1},
+		},
+		{
+			name:      "the first broken rule wins",
+			tasks:     []models.Task{strikes, restart},
+			days:      settled(4, map[int][]uuid.UUID{1: {restart.ID}, 2: {restart.ID}, 3: {restart.ID}}),
+			want:      ActionFail,
+			day:       3,
+			triggered: &strikes,
+			used:      map[uuid.UUID]int{strikes.ID: 4},
+		},
+		{
+			name:  "weekdays task on days off",
+			tasks: []models.Task{weekdays},
+			// Monday to Sunday, done on Monday, Wednesday and Friday only
+			days: settled(7, map[int][]uuid.UUID{1: {weekdays.ID}, 3: {weekdays.ID}, 5: {weekdays.ID}}),
+			want: ActionNone,
+		},
+		{
+			name:      "weekdays task missed on a due day",
+			tasks:     []models.Task{weekdays},
+			days:      settled(7, map[int][]uuid.UUID{1: {weekdays.ID}, 3: {weekdays.ID}}),
+			want:      ActionReset,
+			day:       5,
+			triggered: &weekdays,
+		},
+		{
+			name:  "times per week task done often enough",
+			tasks: []models.Task{thrice},
+			days:  settled(7, map[int][]uuid.UUID{2: {thrice.ID}, 4: {thrice.ID}, 7: {thrice.ID}}),
+			want:  ActionNone,
+		},
+		{
+			name:      "times per week task judged at the end of the week",
+			tasks:     []models.Task{thrice},
+			days:      settled(7, map[int][]uuid.UUID{2: {thrice.ID}, 4: {thrice.ID}}),
+			want:      ActionReset,
+			day:       7,
+			triggered: &thrice,
+		},
+		{
+			name:  "times per week completions on an unsettled day count",
+			tasks: []models.Task{thrice},
+			days: func() []Day {
+				d := settled(7, map[int][]uuid.UUID{2: {thrice.ID}, 4: {thrice.ID}, 6: {thrice.ID}})
+				d[5].Settled = false
+				return d
+			}(),
+			want: ActionNone,
+		},
+		{
+			name:  "task added later is not judged before it existed",
+			tasks: []models.Task{restart},
+			days: func() []Day {
+				d := settled(3, nil)
+				d[0].Tasks, d[1].Tasks = []models.Task{}, []models.Task{}
+				d[2].Completed[restart.ID] = true
+				return d
+			}(),
+			want: ActionNone,
+		},
+		{
+			name:  "day judged against the revision in effect on it",
+			tasks: []models.Task{restart},
+			days: func() []Day {
+				// Workout was optional until day 3
+				d := settled(3, map[int][]uuid.UUID{3: {restart.ID}})
+				earlier := restart
+				earlier.Required, earlier.RestartOnFail = false, false
+				d[0].Tasks, d[1].Tasks = []models.Task{earlier}, []models.Task{earlier}
+				return d
+			}(),
+			want: ActionNone,
+		},
+		{
+			name:  "strike limit of the revision in effect",
+			tasks: []models.Task{strikes},
+			days: func() []Day {
+				// Water allowed a single strike before its limit was raised
+				d := settled(3, map[int][]uuid.UUID{3: {strikes.ID}})
+				earlier := strikes
+				earlier.StrikesLimit = 1
+				d[0].Tasks, d[1].Tasks = []models.Task{earlier}, []models.Task{earlier}
+				return d
+			}(),
+			want:      ActionFail,
+			day:       2,
+			triggered: &strikes,
+			used:      map[uuid.UUID]int{strikes.ID: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.days {
+				if tt.days[i].Tasks == nil {
+					tt.days[i].Tasks = tt.tasks
+				}
+			}
+			verdict := Evaluate(tt.tasks, monday, tt.days)
+
+			if verdict.Action != tt.want {
+				t.Fatalf("action = %s (%s), want %s", verdict.Action, verdict.Reason, tt.want)
+			}
+			if verdict.DayNumber != tt.day {
+				t.Errorf("day = %d, want %d", verdict.DayNumber, tt.day)
+			}
+			switch {
+			case tt.triggered == nil && verdict.TriggeredBy != nil:
+				t.Errorf("triggered by %s, want nothing", verdict.TriggeredBy.TaskName)
+			case tt.triggered != nil && (verdict.TriggeredBy == nil || verdict.TriggeredBy.TaskID != tt.triggered.ID):
+				t.Errorf("triggered by %+v, want %s", verdict.TriggeredBy, tt.triggered.Name)
+			case tt.want != ActionNone && verdict.Reason == "":
+				t.Error("verdict has no reason")
+			}
+
+			if verdict.Strikes == nil {
+				t.Fatal("strikes is nil, want a list")
+			}
+			if len(verdict.Strikes) != len(tt.used) {
+				t.Fatalf("strikes = %+v, want %d tasks", verdict.Strikes, len(tt.used))
+			}
+			for _, s := range verdict.Strikes {
+				want, ok := tt.used[s.TaskID]
+				if !ok {
+					t.Errorf("unexpected strikes for %s", s.TaskName)
+					continue
+				}
+				if s.StrikesUsed != want {
+					t.Errorf("%s used %d strikes, want %d", s.TaskName, s.StrikesUsed, want)
+				}
+				if remaining := max(s.StrikesLimit-want, 0); s.StrikesRemaining != remaining {
+					t.Errorf("%s has %d strikes remaining, want %d", s.TaskName, s.StrikesRemaining, remaining)
+				}
+			}
+		})
+	}
+}
+
+func TestLockedAtAcrossDST(t *testing.T) {
+	zero, six := 0, 6
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		timezone string
+		grace    *int
+		day      int
+		want     time.Time
+	}{
+		{
+			name:     "default grace in UTC",
+			start:    time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			timezone: "UTC",
+			day:      1,
+			want:     time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day before spring forward in New York",
+			start:    time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+			timezone: "America/New_York",
+			grace:    &zero,
+			day:      1,
+			want:     time.Date(2025, 3, 9, 5, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "spring forward day in New York",
+			start:    time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC),
+			timezone: "America/New_York",
+			grace:    &zero,
+			day:      2,
+			want:     time.Date(2025, 3, 10, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "fall back day in New York with grace",
+			start:    time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			timezone: "America/New_York",
+			grace:    &six,
+			day:      2,
+			want:     time.Date(2025, 11, 3, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "fall back day in Berlin",
+			start:    time.Date(2025, 10, 25, 0, 0, 0, 0, time.UTC),
+			timezone: "Europe/Berlin",
+			grace:    &zero,
+			day:      2,
+			want:     time.Date(2025, 10, 26, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &models.Challenge{StartDate: tt.start, DurationDays: 30, EffectiveTimezone: tt.timezone, GraceHours: tt.grace}
+
+			got := LockedAt(c, tt.day)
+			if !got.Equal(tt.want) {
+				t.Fatalf("LockedAt = %v, want %v", got.UTC(), tt.want)
+			}
+			if Locked(c, tt.day, tt.want.Add(-time.Second)) {
+				t.Error("locked a second early")
+			}
+			if !Locked(c, tt.day, tt.want) {
+				t.Error("not locked on time")
+			}
+		})
+	}
+}
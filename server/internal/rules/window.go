@@ -0,0 +1,40 @@
+package rules
+
+import (
+	"time"
+
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+)
+
+const (
+	// DefaultGraceHours is how long a day stays open after it ends, for
+	// challenges without a grace window of their own
+	DefaultGraceHours = 24
+	// MaxGraceHours bounds the grace window of a challenge
+	MaxGraceHours = 168
+)
+
+// Grace returns the grace window of c: how long after a day ends its entry
+// can still be logged or edited
+func Grace(c *models.Challenge) time.Duration {
+	hours := DefaultGraceHours
+	if c.GraceHours != nil {
+		hours = *c.GraceHours
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// LockedAt returns when day of the current attempt locks: at the end of the
+// day in the challenge's timezone, plus the grace window
+func LockedAt(c *models.Challenge, day int) time.Time {
+	date := schedule.CalendarOf(c).Date(day)
+	return dates.EndOf(date, dates.Location(c.EffectiveTimezone)).Add(Grace(c))
+}
+
+// Locked reports whether day can no longer be changed at now. Locked days
+// are final, so the rules only judge a missed day once it is locked.
+func Locked(c *models.Challenge, day int, now time.Time) bool {
+	return !now.Before(LockedAt(c, day))
+}
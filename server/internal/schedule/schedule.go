@@ -0,0 +1,185 @@
+// Package schedule decides on which days of a challenge a task is due.
+//
+// Most schedules pin a task to fixed days. A times-per-week task may be done
+// on any day of a challenge week (days 1-7, 8-14, ...) and is only judged on
+// the last day of the week, by how often it was done that week. A final week
+// cut short by the end of the challenge asks for proportionally fewer days.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// Schedule kinds
+const (
+	Daily        = "daily"
+	Weekdays     = "weekdays"
+	EveryNDays   = "every_n_days"
+	TimesPerWeek = "times_per_week"
+	Days         = "days"
+)
+
+const weekLength = 7
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Calendar places the day numbers of a challenge's current attempt
+type Calendar struct {
+	Start  time.Time
+	Length int
+}
+
+// CalendarOf returns the calendar of a challenge's current attempt
+func CalendarOf(c *models.Challenge) Calendar {
+	return Calendar{Start: c.StartDate, Length: c.DurationDays}
+}
+
+// Date returns the calendar date of day
+func (c Calendar) Date(day int) time.Time {
+	return c.Start.AddDate(0, 0, day-1)
+}
+
+// Day returns the day number that falls on date. It is below 1 before the
+// challenge starts and past Length once it is over.
+func (c Calendar) Day(date time.Time) int {
+	return dates.Between(c.Start, date) + 1
+}
+
+// History holds the days on which a task was completed
+type History map[int]bool
+
+// HistoryOf builds a history from completed day numbers
+func HistoryOf(days []int) History {
+	h := make(History, len(days))
+	for _, day := range days {
+		h[day] = true
+	}
+	return h
+}
+
+// Validate checks a schedule
+func Validate(s models.TaskSchedule) error {
+	switch s.Kind {
+	case "", Daily:
+	case Weekdays:
+		if len(s.Weekdays) == 0 {
+			return errors.New("weekdays must list at least one day")
+		}
+		for _, name := range s.Weekdays {
+			if _, ok := weekdays[name]; !ok {
+				return fmt.Errorf("weekday %q must be one of sun, mon, tue, wed, thu, fri or sat", name)
+			}
+		}
+	case EveryNDays:
+		if s.Every < 1 {
+			return errors.New("every must be at least 1")
+		}
+	case TimesPerWeek:
+		if s.TimesPerWeek < 1 || s.TimesPerWeek > weekLength {
+			return errors.New("times_per_week must be from 1 to 7")
+		}
+	case Days:
+		if len(s.Days) == 0 {
+			return errors.New("days must list at least one day number")
+		}
+		for _, day := range s.Days {
+			if day < 1 {
+				return errors.New("day numbers start at 1")
+			}
+		}
+	default:
+		return fmt.Errorf("kind must be one of %s, %s, %s, %s or %s", Daily, Weekdays, EveryNDays, TimesPerWeek, Days)
+	}
+	return nil
+}
+
+// Due reports whether the task shows up on day. A times-per-week task is due
+// on every day of the week until it has been done often enough.
+func Due(s models.TaskSchedule, cal Calendar, day int, done History) bool {
+	if day < 1 || (cal.Length > 0 && day > cal.Length) {
+		return false
+	}
+
+	switch s.Kind {
+	case Weekdays:
+		weekday := cal.Date(day).Weekday()
+		for _, name := range s.Weekdays {
+			if weekdays[name] == weekday {
+				return true
+			}
+		}
+		return false
+	case EveryNDays:
+		return s.Every > 0 && (day-1)%s.Every == 0
+	case TimesPerWeek:
+		start, end := week(cal, day)
+		return count(done, start, day-1) < weeklyTarget(s, start, end)
+	case Days:
+		for _, d := range s.Days {
+			if d == day {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// Missed reports whether the task counts as missed on day given the days it
+// was done on. A times-per-week task can only be missed on the last day of a
+// week, when it was done fewer times than required that week.
+func Missed(s models.TaskSchedule, cal Calendar, day int, done History) bool {
+	if s.Kind != TimesPerWeek {
+		return Due(s, cal, day, done) && !done[day]
+	}
+
+	start, end := week(cal, day)
+	return day == end && count(done, start, end) < weeklyTarget(s, start, end)
+}
+
+// weeklyTarget returns how often a times-per-week task must be done in the
+// week from start to end. A shorter final week scales the target down by its
+// length, rounding up, so it can always be met.
+func weeklyTarget(s models.TaskSchedule, start, end int) int {
+	days := end - start + 1
+	if days >= weekLength {
+		return s.TimesPerWeek
+	}
+	return (s.TimesPerWeek*days + weekLength - 1) / weekLength
+}
+
+// week returns the first and last day of the challenge week holding day. The
+// final week is cut short by the end of the challenge.
+func week(cal Calendar, day int) (start, end int) {
+	start = (day-1)/weekLength*weekLength + 1
+	end = start + weekLength - 1
+	if cal.Length > 0 && end > cal.Length {
+		end = cal.Length
+	}
+	return start, end
+}
+
+// count returns how many days from first to last the task was done on
+func count(done History, first, last int) int {
+	n := 0
+	for day := first; day <= last; day++ {
+		if done[day] {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,239 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST cases load named timezones
+
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestCalendarDateAcrossDST(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name  string
+		start time.Time
+		day   int
+		want  time.Time
+	}{
+		{"New York spring forward", time.Date(2025, 3, 8, 0, 0, 0, 0, newYork), 3, time.Date(2025, 3, 10, 0, 0, 0, 0, newYork)},
+		{"New York a week over spring forward", time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), 8, time.Date(2025, 3, 10, 0, 0, 0, 0, newYork)},
+		{"New York fall back", time.Date(2025, 11, 1, 0, 0, 0, 0, newYork), 3, time.Date(2025, 11, 3, 0, 0, 0, 0, newYork)},
+		{"Berlin fall back", time.Date(2025, 10, 25, 0, 0, 0, 0, berlin), 3, time.Date(2025, 10, 27, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, before := tt.start.Zone()
+			_, after := tt.want.Zone()
+			if before == after {
+				t.Fatalf("%v and %v share an offset; the case does not cross a DST change", tt.start, tt.want)
+			}
+
+			cal := Calendar{Start: tt.start, Length: 30}
+			if got := cal.Date(tt.day); !got.Equal(tt.want) {
+				t.Errorf("Date(%d) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendarDay(t *testing.T) {
+	cal := Calendar{Start: time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), Length: 30}
+
+	tests := []struct {
+		date time.Time
+		want int
+	}{
+		{time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), 1},
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), 3},
+		{time.Date(2025, 4, 6, 0, 0, 0, 0, time.UTC), 30},
+		{time.Date(2025, 4, 7, 0, 0, 0, 0, time.UTC), 31},
+	}
+
+	for _, tt := range tests {
+		if got := cal.Day(tt.date); got != tt.want {
+			t.Errorf("Day(%s) = %d, want %d", tt.date.Format(time.DateOnly), got, tt.want)
+		}
+		if tt.want >= 1 && !cal.Date(tt.want).Equal(tt.date) {
+			t.Errorf("Date(%d) = %v, want %v", tt.want, cal.Date(tt.want), tt.date)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       models.TaskSchedule
+		wantErr bool
+	}{
+		{"empty kind is daily", models.TaskSchedule{}, false},
+		{"daily", models.TaskSchedule{Kind: Daily}, false},
+		{"weekdays", models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"mon", "fri"}}, false},
+		{"no weekdays", models.TaskSchedule{Kind: Weekdays}, true},
+		{"unknown weekday", models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"monday"}}, true},
+		{"every 2 days", models.TaskSchedule{Kind: EveryNDays, Every: 2}, false},
+		{"every 0 days", models.TaskSchedule{Kind: EveryNDays}, true},
+		{"3 times per week", models.TaskSchedule{Kind: TimesPerWeek, TimesPerWeek: 3}, false},
+		{"0 times per week", models.TaskSchedule{Kind: TimesPerWeek}, true},
+		{"8 times per week", models.TaskSchedule{Kind: TimesPerWeek, TimesPerWeek: 8}, true},
+		{"days", models.TaskSchedule{Kind: Days, Days: []int{1, 30}}, false},
+		{"no days", models.TaskSchedule{Kind: Days}, true},
+		{"day 0", models.TaskSchedule{Kind: Days, Days: []int{0}}, true},
+		{"unknown kind", models.TaskSchedule{Kind: "monthly"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.s); (err != nil) != tt.wantErr {
+				t.Errorf("Validate = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDue(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	// 2025-03-03 is a Monday; US clocks spring forward on Sunday 2025-03-09
+	monday := Calendar{Start: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Length: 14}
+	mondayNewYork := Calendar{Start: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), Length: 14}
+	short := Calendar{Start: monday.Start, Length: 10}
+	// 2025-11-01 is a Saturday; US clocks fall back on Sunday 2025-11-02
+	saturdayNewYork := Calendar{Start: time.Date(2025, 11, 1, 0, 0, 0, 0, newYork), Length: 14}
+	// 2025-10-25 is a Saturday; European clocks fall back on Sunday 2025-10-26
+	saturdayBerlin := Calendar{Start: time.Date(2025, 10, 25, 0, 0, 0, 0, berlin), Length: 14}
+	open := Calendar{Start: monday.Start}
+
+	mwf := models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"mon", "wed", "fri"}}
+	sunday := models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"sun"}}
+	monOnly := models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"mon"}}
+	every3 := models.TaskSchedule{Kind: EveryNDays, Every: 3}
+	thrice := models.TaskSchedule{Kind: TimesPerWeek, TimesPerWeek: 3}
+	someDays := models.TaskSchedule{Kind: Days, Days: []int{1, 5}}
+
+	tests := []struct {
+		name string
+		s    models.TaskSchedule
+		cal  Calendar
+		day  int
+		done History
+		want bool
+	}{
+		{"daily on day 1", models.TaskSchedule{}, monday, 1, nil, true},
+		{"daily on the last day", models.TaskSchedule{Kind: Daily}, monday, 14, nil, true},
+		{"daily before day 1", models.TaskSchedule{}, monday, 0, nil, false},
+		{"daily past the end", models.TaskSchedule{}, monday, 15, nil, false},
+		{"daily without an end", models.TaskSchedule{}, open, 400, nil, true},
+
+		{"weekdays on a Monday", mwf, monday, 1, nil, true},
+		{"weekdays on a Tuesday", mwf, monday, 2, nil, false},
+		{"weekdays on a Wednesday", mwf, monday, 3, nil, true},
+		{"weekdays on the spring forward Sunday", mwf, mondayNewYork, 7, nil, false},
+		{"weekdays on the Monday after spring forward", mwf, mondayNewYork, 8, nil, true},
+		{"weekdays on the Wednesday after spring forward", mwf, mondayNewYork, 10, nil, true},
+		{"Sunday on the spring forward Sunday in New York", sunday, mondayNewYork, 7, nil, true},
+		{"Monday after spring forward in New York", monOnly, mondayNewYork, 8, nil, true},
+		{"Sunday on the fall back Sunday in New York", sunday, saturdayNewYork, 2, nil, true},
+		{"Sunday a week after fall back in New York", sunday, saturdayNewYork, 9, nil, true},
+		{"Monday after fall back in New York", monOnly, saturdayNewYork, 3, nil, true},
+		{"Saturday of fall back in New York", sunday, saturdayNewYork, 1, nil, false},
+		{"Sunday on the fall back Sunday in Berlin", sunday, saturdayBerlin, 2, nil, true},
+		{"Monday after fall back in Berlin", monOnly, saturdayBerlin, 3, nil, true},
+
+		{"every 3 days on day 1", every3, monday, 1, nil, true},
+		{"every 3 days on day 2", every3, monday, 2, nil, false},
+		{"every 3 days on day 4", every3, monday, 4, nil, true},
+		{"every 3 days across spring forward", every3, mondayNewYork, 7, nil, true},
+		{"every 0 days", models.TaskSchedule{Kind: EveryNDays}, monday, 1, nil, false},
+
+		{"times per week not yet done", thrice, monday, 3, History{1: true, 2: true}, true},
+		{"times per week done on the day", thrice, monday, 3, History{1: true, 2: true, 3: true}, true},
+		{"times per week done often enough", thrice, monday, 4, History{1: true, 2: true, 3: true}, false},
+		{"times per week in a new week", thrice, monday, 8, History{1: true, 2: true, 3: true}, true},
+		{"times per week met pro rata in a cut short final week", thrice, short, 10, History{8: true, 9: true}, false},
+		{"times per week short in a cut short final week", thrice, short, 10, History{8: true}, true},
+
+		{"listed day", someDays, monday, 5, nil, true},
+		{"unlisted day", someDays, monday, 4, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Due(tt.s, tt.cal, tt.day, tt.done); got != tt.want {
+				t.Errorf("Due(day %d) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMissed(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+
+	monday := Calendar{Start: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), Length: 14}
+	mondayNewYork := Calendar{Start: time.Date(2025, 3, 3, 0, 0, 0, 0, newYork), Length: 14}
+	saturdayNewYork := Calendar{Start: time.Date(2025, 11, 1, 0, 0, 0, 0, newYork), Length: 14}
+	short := Calendar{Start: monday.Start, Length: 10}
+	full := Calendar{Start: monday.Start, Length: 75}
+
+	mwf := models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"mon", "wed", "fri"}}
+	sunday := models.TaskSchedule{Kind: Weekdays, Weekdays: []string{"sun"}}
+	thrice := models.TaskSchedule{Kind: TimesPerWeek, TimesPerWeek: 3}
+	six := models.TaskSchedule{Kind: TimesPerWeek, TimesPerWeek: 6}
+
+	tests := []struct {
+		name string
+		s    models.TaskSchedule
+		cal  Calendar
+		day  int
+		done History
+		want bool
+	}{
+		{"daily not done", models.TaskSchedule{}, monday, 2, History{1: true}, true},
+		{"daily done", models.TaskSchedule{}, monday, 2, History{2: true}, false},
+		{"daily past the end", models.TaskSchedule{}, monday, 15, nil, false},
+
+		{"weekdays not done on a due day", mwf, monday, 3, nil, true},
+		{"weekdays done on a due day", mwf, monday, 3, History{3: true}, false},
+		{"weekdays on a day off", mwf, monday, 2, nil, false},
+		{"weekdays on the spring forward Sunday", mwf, mondayNewYork, 7, nil, false},
+		{"weekdays on the Monday after spring forward", mwf, mondayNewYork, 8, nil, true},
+		{"Sunday task on the fall back Sunday", sunday, saturdayNewYork, 2, nil, true},
+		{"Sunday task done on the fall back Sunday", sunday, saturdayNewYork, 2, History{2: true}, false},
+		{"Sunday task on the Saturday before fall back", sunday, saturdayNewYork, 1, nil, false},
+
+		{"times per week mid-week", thrice, monday, 6, nil, false},
+		{"times per week short at the end of the week", thrice, monday, 7, History{1: true, 3: true}, true},
+		{"times per week met at the end of the week", thrice, monday, 7, History{1: true, 3: true, 5: true}, false},
+		{"times per week met on the last day", thrice, monday, 7, History{5: true, 6: true, 7: true}, false},
+		{"times per week done in the previous week", thrice, monday, 14, History{5: true, 6: true, 7: true}, true},
+		{"times per week in a cut short final week", thrice, short, 10, History{8: true}, true},
+		{"times per week met in a cut short final week", thrice, short, 10, History{8: true, 9: true, 10: true}, false},
+		{"times per week before a cut short final week ends", thrice, short, 9, nil, false},
+		{"times per week met pro rata in a cut short final week", thrice, short, 10, History{8: true, 10: true}, false},
+		{"six times per week met in a five day final week", six, full, 75, History{71: true, 72: true, 73: true, 74: true, 75: true}, false},
+		{"six times per week short in a five day final week", six, full, 75, History{71: true, 72: true, 73: true, 74: true}, true},
+		{"six times per week in a full week", six, full, 70, History{64: true, 65: true, 66: true, 67: true, 68: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Missed(tt.s, tt.cal, tt.day, tt.done); got != tt.want {
+				t.Errorf("Missed(day %d) = %v, want %v", tt.day, got, tt.want)
+			}
+		})
+	}
+}
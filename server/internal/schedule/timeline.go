@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/dates"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// Timeline resolves the tasks of a challenge as they were defined on each day
+// of its current attempt. A revision is in effect from the day it was made, in
+// the challenge's timezone, until the next one; an archived task drops out on
+// the day it was archived. Tasks set up by the day the challenge was created
+// count from day 1, so a challenge started in the past can be backfilled.
+type Timeline struct {
+	cal   Calendar
+	loc   *time.Location
+	setup time.Time
+	order []uuid.UUID
+	// revisions holds the revisions of each task, oldest first
+	revisions map[uuid.UUID][]models.Task
+}
+
+// NewTimeline builds the timeline of c from every revision of its tasks,
+// ordered by section, task and revision as TaskStore.ListTaskRevisions
+// returns them
+func NewTimeline(c *models.Challenge, revisions []models.Task) *Timeline {
+	loc := dates.Location(c.EffectiveTimezone)
+	t := &Timeline{
+		cal:       CalendarOf(c),
+		loc:       loc,
+		setup:     dates.Of(c.CreatedAt, loc),
+		revisions: map[uuid.UUID][]models.Task{},
+	}
+
+	for _, rev := range revisions {
+		if _, ok := t.revisions[rev.ID]; !ok {
+			t.order = append(t.order, rev.ID)
+		}
+		t.revisions[rev.ID] = append(t.revisions[rev.ID], rev)
+	}
+	return t
+}
+
+// On returns the tasks in effect on day, each as it was defined that day
+func (t *Timeline) On(day int) []models.Task {
+	tasks := []models.Task{}
+	for _, id := range t.order {
+		if task, ok := t.Task(id, day); ok {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// Task returns the revision of a task in effect on day. It reports false
+// when the task did not exist yet that day or had been archived.
+func (t *Timeline) Task(id uuid.UUID, day int) (models.Task, bool) {
+	date := t.cal.Date(day)
+
+	var found *models.Task
+	for i, rev := range t.revisions[id] {
+		made := dates.Of(rev.CreatedAt, t.loc)
+		if i == 0 && !made.After(t.setup) {
+			made = time.Time{}
+		}
+		if made.After(date) {
+			break
+		}
+		found = &t.revisions[id][i]
+	}
+
+	if found == nil {
+		return models.Task{}, false
+	}
+	if found.ArchivedAt != nil && !dates.Of(*found.ArchivedAt, t.loc).After(date) {
+		return models.Task{}, false
+	}
+	return *found, true
+}
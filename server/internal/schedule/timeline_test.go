@@ -0,0 +1,88 @@
+package schedule
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+func TestTimeline(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	// A ten day challenge set up on 2025-02-20 to start on Monday 2025-03-03
+	challenge := &models.Challenge{
+		StartDate:         time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		DurationDays:      10,
+		EffectiveTimezone: "America/New_York",
+		CreatedAt:         time.Date(2025, 2, 20, 12, 0, 0, 0, time.UTC),
+	}
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 3, 2+day, hour, 0, 0, 0, newYork)
+	}
+
+	setup, edited, added, archived := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	archivedAt := at(6, 20)
+	timeline := NewTimeline(challenge, []models.Task{
+		{ID: setup, Revision: 1, CreatedAt: challenge.CreatedAt},
+		{ID: edited, Revision: 1, CreatedAt: challenge.CreatedAt.Add(time.Hour)},
+		{ID: edited, Revision: 2, Required: true, CreatedAt: at(3, 9)},
+		// Late in the evening of day 4 in New York, already day 5 in UTC
+		{ID: added, Revision: 1, CreatedAt: at(4, 22)},
+		{ID: archived, Revision: 1, CreatedAt: challenge.CreatedAt, ArchivedAt: &archivedAt},
+	})
+
+	tests := []struct {
+		day  int
+		want []uuid.UUID
+	}{
+		{1, []uuid.UUID{setup, edited, archived}},
+		{3, []uuid.UUID{setup, edited, archived}},
+		{4, []uuid.UUID{setup, edited, added, archived}},
+		{6, []uuid.UUID{setup, edited, added}},
+		{10, []uuid.UUID{setup, edited, added}},
+	}
+
+	for _, tt := range tests {
+		var got []uuid.UUID
+		for _, task := range timeline.On(tt.day) {
+			got = append(got, task.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("On(%d) = %v, want %v", tt.day, got, tt.want)
+		}
+	}
+
+	for day, want := range map[int]int{1: 1, 2: 1, 3: 2, 10: 2} {
+		if task, ok := timeline.Task(edited, day); !ok || task.Revision != want {
+			t.Errorf("Task(edited, %d) = revision %d, %v, want revision %d", day, task.Revision, ok, want)
+		}
+	}
+	if _, ok := timeline.Task(uuid.New(), 1); ok {
+		t.Error("Task found a task the challenge never had")
+	}
+}
+
+func TestTimelineTaskAddedAfterSetup(t *testing.T) {
+	// A challenge started in the past counts the tasks it was set up with
+	// from day 1, but a task added later only from the day it was added
+	challenge := &models.Challenge{
+		StartDate:         time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		DurationDays:      10,
+		EffectiveTimezone: "UTC",
+		CreatedAt:         time.Date(2025, 3, 6, 8, 0, 0, 0, time.UTC),
+	}
+	setup, later := uuid.New(), uuid.New()
+	timeline := NewTimeline(challenge, []models.Task{
+		{ID: setup, Revision: 1, CreatedAt: challenge.CreatedAt.Add(time.Minute)},
+		{ID: later, Revision: 1, CreatedAt: time.Date(2025, 3, 7, 8, 0, 0, 0, time.UTC)},
+	})
+
+	if got := timeline.On(1); len(got) != 1 || got[0].ID != setup {
+		t.Errorf("On(1) = %v, want only the task it was set up with", got)
+	}
+	if got := timeline.On(5); len(got) != 2 {
+		t.Errorf("On(5) = %v, want both tasks", got)
+	}
+}
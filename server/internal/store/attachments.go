@@ -0,0 +1,118 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+const attachmentColumns = `a.id, a.daily_entry_id, a.task_entry_id, te.task_id, d.challenge_id, d.attempt_id, d.day_number, d.date,
+	a.kind, a.mime_type, a.size_bytes, a.blob_key, COALESCE(a.thumbnail_key, ''), a.order_index,
+	COALESCE(a.caption, ''), a.created_at, a.updated_at`
+
+// attachmentTables joins an attachment to the daily entry it belongs to,
+// directly or through its task entry
+const attachmentTables = `a
+	LEFT JOIN task_entries te ON a.task_entry_id = te.id
+	JOIN daily_entries d ON d.id = COALESCE(a.daily_entry_id, te.daily_entry_id)`
+
+func scanAttachment(row scanner, a *models.Attachment) error {
+	return row.Scan(&a.ID, &a.DailyEntryID, &a.TaskEntryID, &a.TaskID, &a.ChallengeID, &a.AttemptID, &a.DayNumber, &a.Date,
+		&a.Kind, &a.MimeType, &a.Size, &a.BlobKey, &a.ThumbnailKey, &a.Order,
+		&a.Caption, &a.CreatedAt, &a.UpdatedAt)
+}
+
+func (p *Postgres) queryAttachments(ctx context.Context, sql string, args ...any) ([]models.Attachment, error) {
+	rows, err := p.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []models.Attachment{}
+	for rows.Next() {
+		var a models.Attachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// ListEntryAttachments implements AttachmentStore
+func (p *Postgres) ListEntryAttachments(ctx context.Context, dailyEntryID uuid.UUID) ([]models.Attachment, error) {
+	return p.queryAttachments(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments `+attachmentTables+`
+		WHERE d.id = $1
+		ORDER BY a.order_index ASC, a.created_at ASC
+	`, dailyEntryID)
+}
+
+// ListChallengeAttachments implements AttachmentStore
+func (p *Postgres) ListChallengeAttachments(ctx context.Context, challengeID uuid.UUID) ([]models.Attachment, error) {
+	return p.queryAttachments(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments `+attachmentTables+`
+		WHERE d.challenge_id = $1
+		ORDER BY d.date ASC, a.task_entry_id NULLS FIRST, a.order_index ASC, a.created_at ASC
+	`, challengeID)
+}
+
+// ListUserAttachments implements AttachmentStore
+func (p *Postgres) ListUserAttachments(ctx context.Context, userID uuid.UUID) ([]models.Attachment, error) {
+	return p.queryAttachments(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments `+attachmentTables+`
+		JOIN challenges c ON d.challenge_id = c.id
+		WHERE c.user_id = $1
+	`, userID)
+}
+
+// GetAttachment implements AttachmentStore
+func (p *Postgres) GetAttachment(ctx context.Context, userID, attachmentID uuid.UUID) (*models.Attachment, error) {
+	var a models.Attachment
+	err := scanAttachment(p.db.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM attachments `+attachmentTables+`
+		JOIN challenges c ON d.challenge_id = c.id
+		WHERE a.id = $1 AND c.user_id = $2
+	`, attachmentID, userID), &a)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &a, nil
+}
+
+// CreateAttachment implements AttachmentStore. Attachments uploaded at the
+// same time may share an order; they are then listed by upload time.
+func (p *Postgres) CreateAttachment(ctx context.Context, a *models.Attachment) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+
+	return notFound(scanAttachment(p.db.QueryRow(ctx, `
+		WITH a AS (
+			INSERT INTO attachments
+			(id, daily_entry_id, task_entry_id, kind, mime_type, size_bytes, blob_key, thumbnail_key,
+			order_index, caption, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), (
+				SELECT COALESCE(MAX(order_index), 0) + 1 FROM attachments
+				WHERE daily_entry_id = $2 OR task_entry_id = $3
+			), NULLIF($9, ''), NOW(), NOW())
+			RETURNING *
+		)
+		SELECT `+attachmentColumns+`
+		FROM `+attachmentTables,
+		a.ID, a.DailyEntryID, a.TaskEntryID, a.Kind, a.MimeType, a.Size, a.BlobKey, a.ThumbnailKey,
+		a.Caption), a))
+}
+
+// DeleteAttachment implements AttachmentStore
+func (p *Postgres) DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error {
+	return requireRow(p.db.Exec(ctx, "DELETE FROM attachments WHERE id = $1", attachmentID))
+}
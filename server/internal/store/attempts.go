@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// attemptColumns reports the challenge's current day as the day reached by
+// an attempt that is still running
+const attemptColumns = `a.id, a.challenge_id, a.attempt_number, a.start_date, a.started_at, a.ended_at,
+	COALESCE(a.end_reason, ''), COALESCE(a.day_reached, c.current_day),
+	(SELECT COUNT(*) FROM daily_entries d WHERE d.attempt_id = a.id),
+	(SELECT COUNT(*) FROM daily_entries d WHERE d.attempt_id = a.id AND d.completed)`
+
+// latestAttempt selects the ID of the newest attempt of the challenge in $1.
+// Entries are always read and written against it.
+const latestAttempt = `(SELECT id FROM attempts WHERE challenge_id = $1 ORDER BY attempt_number DESC LIMIT 1)`
+
+func scanAttempt(row scanner, a *models.Attempt) error {
+	return row.Scan(&a.ID, &a.ChallengeID, &a.AttemptNumber, &a.StartDate, &a.StartedAt, &a.EndedAt,
+		&a.EndReason, &a.DayReached, &a.EntriesLogged, &a.CompletedDays)
+}
+
+// ListAttempts implements AttemptStore
+func (p *Postgres) ListAttempts(ctx context.Context, challengeID uuid.UUID) ([]models.Attempt, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+attemptColumns+`
+		FROM attempts a
+		JOIN challenges c ON a.challenge_id = c.id
+		WHERE a.challenge_id = $1
+		ORDER BY a.attempt_number ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.Attempt{}
+	for rows.Next() {
+		var a models.Attempt
+		if err := scanAttempt(rows, &a); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// CurrentAttempt implements AttemptStore
+func (p *Postgres) CurrentAttempt(ctx context.Context, challengeID uuid.UUID) (*models.Attempt, error) {
+	var a models.Attempt
+	err := scanAttempt(p.db.QueryRow(ctx, `
+		SELECT `+attemptColumns+`
+		FROM attempts a
+		JOIN challenges c ON a.challenge_id = c.id
+		WHERE a.challenge_id = $1
+		ORDER BY a.attempt_number DESC
+		LIMIT 1
+	`, challengeID), &a)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &a, nil
+}
+
+// startAttempt opens the next attempt of a challenge
+func (p *Postgres) startAttempt(ctx context.Context, challengeID uuid.UUID, startDate any) error {
+	_, err := p.db.Exec(ctx, `
+		INSERT INTO attempts (challenge_id, attempt_number, start_date, started_at)
+		VALUES ($1, (SELECT COALESCE(MAX(attempt_number), 0) + 1 FROM attempts WHERE challenge_id = $1), $2, NOW())
+	`, challengeID, startDate)
+	return err
+}
+
+// endAttempt closes the running attempt of a challenge, if there is one
+func (p *Postgres) endAttempt(ctx context.Context, challengeID uuid.UUID, reason string, dayReached int) error {
+	_, err := p.db.Exec(ctx, `
+		UPDATE attempts
+		SET ended_at = NOW(), end_reason = $2, day_reached = $3
+		WHERE challenge_id = $1 AND ended_at IS NULL
+	`, challengeID, reason, dayReached)
+	return err
+}
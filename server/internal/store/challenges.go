@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// challengeColumns resolves the effective timezone through the owning user, so
+// the table must not be aliased
+const challengeColumns = `id, user_id, name, COALESCE(description, ''), start_date, end_date,
+	duration_days, current_day, status, COALESCE(timezone, ''),
+	COALESCE(timezone, (SELECT u.timezone FROM users u WHERE u.id = challenges.user_id), 'UTC'),
+	grace_hours, created_at, updated_at`
+
+func scanChallenge(row scanner, c *models.Challenge) error {
+	var endDate *time.Time
+	if err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Description, &c.StartDate, &endDate,
+		&c.DurationDays, &c.CurrentDay, &c.Status, &c.Timezone, &c.EffectiveTimezone,
+		&c.GraceHours, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return err
+	}
+	c.EndDate = timeValue(endDate)
+	return nil
+}
+
+// ListChallenges implements ChallengeStore
+func (p *Postgres) ListChallenges(ctx context.Context, userID uuid.UUID) ([]models.Challenge, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+challengeColumns+`
+		FROM challenges
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	challenges := []models.Challenge{}
+	for rows.Next() {
+		var c models.Challenge
+		if err := scanChallenge(rows, &c); err != nil {
+			return nil, err
+		}
+		challenges = append(challenges, c)
+	}
+
+	return challenges, rows.Err()
+}
+
+// GetChallenge implements ChallengeStore
+func (p *Postgres) GetChallenge(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error) {
+	var c models.Challenge
+	err := scanChallenge(p.db.QueryRow(ctx, `
+		SELECT `+challengeColumns+`
+		FROM challenges
+		WHERE id = $1 AND user_id = $2
+	`, challengeID, userID), &c)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &c, nil
+}
+
+// CreateChallenge implements ChallengeStore and opens its first attempt
+func (p *Postgres) CreateChallenge(ctx context.Context, c *models.Challenge) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		err := scanChallenge(tx.db.QueryRow(ctx, `
+			INSERT INTO challenges (id, user_id, name, description, start_date, end_date, duration_days, current_day, status, timezone, grace_hours, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''), $11, NOW(), NOW())
+			RETURNING `+challengeColumns,
+			c.ID, c.UserID, c.Name, c.Description, c.StartDate, nullTime(c.EndDate), c.DurationDays, c.CurrentDay, c.Status, c.Timezone, c.GraceHours), c)
+		if err != nil {
+			return err
+		}
+
+		return tx.startAttempt(ctx, c.ID, c.StartDate)
+	})
+}
+
+// UpdateChallenge implements ChallengeStore
+func (p *Postgres) UpdateChallenge(ctx context.Context, c *models.Challenge) error {
+	err := scanChallenge(p.db.QueryRow(ctx, `
+		UPDATE challenges
+		SET name = $1, description = $2, end_date = $3, duration_days = $4,
+		    timezone = NULLIF($5, ''), grace_hours = $6, updated_at = NOW()
+		WHERE id = $7 AND user_id = $8
+		RETURNING `+challengeColumns,
+		c.Name, c.Description, nullTime(c.EndDate), c.DurationDays, c.Timezone, c.GraceHours, c.ID, c.UserID), c)
+	return notFound(err)
+}
+
+// DeleteChallenge implements ChallengeStore. Sections, tasks, entries and
+// measurements are removed by ON DELETE CASCADE.
+func (p *Postgres) DeleteChallenge(ctx context.Context, userID, challengeID uuid.UUID) error {
+	return requireRow(p.db.Exec(ctx, "DELETE FROM challenges WHERE id = $1 AND user_id = $2", challengeID, userID))
+}
+
+// ResetChallenge implements ChallengeStore. The running attempt is closed
+// with reason and the day it reached, and a new attempt starts on startDate.
+// Entries of earlier attempts are kept.
+func (p *Postgres) ResetChallenge(ctx context.Context, userID, challengeID uuid.UUID, reason string, startDate time.Time) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var currentDay int
+		err := tx.db.QueryRow(ctx, `
+			SELECT current_day FROM challenges WHERE id = $1 AND user_id = $2 FOR UPDATE
+		`, challengeID, userID).Scan(&currentDay)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if err := tx.endAttempt(ctx, challengeID, reason, currentDay); err != nil {
+			return err
+		}
+
+		if err := tx.startAttempt(ctx, challengeID, startDate); err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(ctx, `
+			UPDATE challenges
+			SET current_day = 1, status = 'active', start_date = $2,
+			    end_date = $2::date + duration_days - 1, updated_at = NOW()
+			WHERE id = $1
+		`, challengeID, startDate)
+		return err
+	})
+}
+
+// SetChallengeDay implements ChallengeStore
+func (p *Postgres) SetChallengeDay(ctx context.Context, challengeID uuid.UUID, day int) error {
+	return requireRow(p.db.Exec(ctx,
+		"UPDATE challenges SET current_day = $1, updated_at = NOW() WHERE id = $2",
+		day, challengeID))
+}
+
+// ClaimDueChallenge implements ChallengeStore. A day is over at midnight in
+// the challenge's effective timezone. Rows locked by another transaction are
+// skipped, so concurrent schedulers never claim the same
+// challenge.
+func (p *Postgres) ClaimDueChallenge(ctx context.Context, skip []uuid.UUID) (*models.Challenge, error) {
+	if skip == nil {
+		skip = []uuid.UUID{}
+	}
+
+	var c models.Challenge
+	err := scanChallenge(p.db.QueryRow(ctx, `
+		SELECT `+challengeColumns+`
+		FROM challenges
+		WHERE status = 'active'
+		  AND start_date + current_day - 1 < (NOW() AT TIME ZONE
+		      COALESCE(timezone, (SELECT u.timezone FROM users u WHERE u.id = challenges.user_id), 'UTC'))::date
+		  AND NOT (id = ANY($1))
+		ORDER BY start_date + current_day ASC
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, skip), &c)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &c, nil
+}
+
+// SetChallengeStatus implements ChallengeStore. Leaving the active status
+// closes the running attempt with the status as its end reason.
+func (p *Postgres) SetChallengeStatus(ctx context.Context, challengeID uuid.UUID, status string) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var currentDay int
+		err := tx.db.QueryRow(ctx,
+			"UPDATE challenges SET status = $1, updated_at = NOW() WHERE id = $2 RETURNING current_day",
+			status, challengeID).Scan(&currentDay)
+		if err != nil {
+			return notFound(err)
+		}
+		if status == "active" {
+			return nil
+		}
+
+		return tx.endAttempt(ctx, challengeID, status, currentDay)
+	})
+}
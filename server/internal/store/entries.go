@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// entryColumns loads the blob keys of the first progress photo; its URLs are
+// signed by the handlers
+const entryColumns = `id, challenge_id, attempt_id, day_number, date, completed, COALESCE(notes, ''),
+	COALESCE((SELECT blob_key FROM attachments a WHERE a.daily_entry_id = daily_entries.id AND a.kind = 'progress_photo'
+		ORDER BY a.order_index, a.created_at LIMIT 1), ''),
+	COALESCE((SELECT thumbnail_key FROM attachments a WHERE a.daily_entry_id = daily_entries.id AND a.kind = 'progress_photo'
+		ORDER BY a.order_index, a.created_at LIMIT 1), ''),
+	COALESCE(energy_level, 0), COALESCE(mood_level, 0), backfilled, edited_at, created_at, updated_at`
+
+func scanEntry(row scanner, e *models.DailyEntry) error {
+	return row.Scan(&e.ID, &e.ChallengeID, &e.AttemptID, &e.DayNumber, &e.Date, &e.Completed, &e.Notes,
+		&e.PhotoKey, &e.ThumbnailKey, &e.EnergyLevel, &e.MoodLevel, &e.Backfilled, &e.EditedAt, &e.CreatedAt, &e.UpdatedAt)
+}
+
+const taskEntryColumns = `id, daily_entry_id, task_id,
+	(SELECT revision FROM task_revisions WHERE id = task_revision_id), completed, value, COALESCE(notes, ''),
+	created_at, updated_at`
+
+func scanTaskEntry(row scanner, te *models.TaskEntry) error {
+	var valueJSON []byte
+	if err := row.Scan(&te.ID, &te.DailyEntryID, &te.TaskID, &te.TaskRevision, &te.Completed, &valueJSON,
+		&te.Notes, &te.CreatedAt, &te.UpdatedAt); err != nil {
+		return err
+	}
+
+	te.Value = nil
+	if len(valueJSON) > 0 {
+		if err := json.Unmarshal(valueJSON, &te.Value); err != nil {
+			return fmt.Errorf("decode task entry value: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListEntries implements EntryStore
+func (p *Postgres) ListEntries(ctx context.Context, challengeID uuid.UUID) ([]models.DailyEntry, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+entryColumns+`
+		FROM daily_entries
+		WHERE attempt_id = `+latestAttempt+`
+		ORDER BY day_number ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.DailyEntry{}
+	for rows.Next() {
+		var e models.DailyEntry
+		if err := scanEntry(rows, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetEntry implements EntryStore
+func (p *Postgres) GetEntry(ctx context.Context, challengeID uuid.UUID, dayNumber int) (*models.DailyEntry, error) {
+	var e models.DailyEntry
+	err := scanEntry(p.db.QueryRow(ctx, `
+		SELECT `+entryColumns+`
+		FROM daily_entries
+		WHERE attempt_id = `+latestAttempt+` AND day_number = $2
+	`, challengeID, dayNumber), &e)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &e, nil
+}
+
+// CreateEntry implements EntryStore
+func (p *Postgres) CreateEntry(ctx context.Context, e *models.DailyEntry) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	return scanEntry(p.db.QueryRow(ctx, `
+		INSERT INTO daily_entries
+		(id, challenge_id, attempt_id, day_number, date, completed, notes,
+		energy_level, mood_level, backfilled, edited_at, created_at, updated_at)
+		VALUES ($2, $1, `+latestAttempt+`, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING `+entryColumns,
+		e.ChallengeID, e.ID, e.DayNumber, e.Date, e.Completed, e.Notes,
+		e.EnergyLevel, e.MoodLevel, e.Backfilled, e.EditedAt), e)
+}
+
+// UpdateEntry implements EntryStore. The day number and date are left unchanged.
+func (p *Postgres) UpdateEntry(ctx context.Context, e *models.DailyEntry) error {
+	err := scanEntry(p.db.QueryRow(ctx, `
+		UPDATE daily_entries
+		SET completed = $1, notes = $2,
+		energy_level = $3, mood_level = $4, backfilled = $5, edited_at = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING `+entryColumns,
+		e.Completed, e.Notes, e.EnergyLevel, e.MoodLevel, e.Backfilled, e.EditedAt, e.ID), e)
+	return notFound(err)
+}
+
+func (p *Postgres) queryTaskEntries(ctx context.Context, sql string, args ...any) ([]models.TaskEntry, error) {
+	rows, err := p.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taskEntries := []models.TaskEntry{}
+	for rows.Next() {
+		var te models.TaskEntry
+		if err := scanTaskEntry(rows, &te); err != nil {
+			return nil, err
+		}
+		taskEntries = append(taskEntries, te)
+	}
+
+	return taskEntries, rows.Err()
+}
+
+// ListTaskEntries implements EntryStore
+func (p *Postgres) ListTaskEntries(ctx context.Context, dailyEntryID uuid.UUID) ([]models.TaskEntry, error) {
+	return p.queryTaskEntries(ctx, `
+		SELECT `+taskEntryColumns+`
+		FROM task_entries
+		WHERE daily_entry_id = $1
+	`, dailyEntryID)
+}
+
+// ListAttemptTaskEntries implements EntryStore
+func (p *Postgres) ListAttemptTaskEntries(ctx context.Context, challengeID uuid.UUID) ([]models.TaskEntry, error) {
+	return p.queryTaskEntries(ctx, `
+		SELECT `+taskEntryColumns+`
+		FROM task_entries
+		WHERE daily_entry_id IN (SELECT id FROM daily_entries WHERE attempt_id = `+latestAttempt+`)
+	`, challengeID)
+}
+
+// ListTaskCompletions implements EntryStore
+func (p *Postgres) ListTaskCompletions(ctx context.Context, challengeID uuid.UUID) (map[uuid.UUID][]int, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT te.task_id, d.day_number
+		FROM task_entries te
+		JOIN daily_entries d ON te.daily_entry_id = d.id
+		WHERE d.attempt_id = `+latestAttempt+` AND te.completed
+		ORDER BY d.day_number ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	completions := map[uuid.UUID][]int{}
+	for rows.Next() {
+		var taskID uuid.UUID
+		var day int
+		if err := rows.Scan(&taskID, &day); err != nil {
+			return nil, err
+		}
+		completions[taskID] = append(completions[taskID], day)
+	}
+
+	return completions, rows.Err()
+}
+
+// UpsertTaskEntry implements EntryStore. A new entry is recorded against
+// te.TaskRevision, or the task's current revision when that is zero; an
+// updated one keeps the revision it was first recorded against, so editing a
+// past day doesn't rewrite its history.
+func (p *Postgres) UpsertTaskEntry(ctx context.Context, te *models.TaskEntry) error {
+	valueJSON, err := json.Marshal(te.Value)
+	if err != nil {
+		return fmt.Errorf("encode task entry value: %w", err)
+	}
+
+	if te.ID == uuid.Nil {
+		te.ID = uuid.New()
+	}
+
+	return scanTaskEntry(p.db.QueryRow(ctx, `
+		INSERT INTO task_entries
+		(id, daily_entry_id, task_id, task_revision_id, completed, value, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, (
+			SELECT r.id FROM task_revisions r JOIN tasks t ON r.task_id = t.id
+			AND r.revision = CASE WHEN $7::int > 0 THEN $7::int ELSE t.revision END
+			WHERE t.id = $3
+		), $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (daily_entry_id, task_id) DO UPDATE
+		SET completed = EXCLUDED.completed, value = EXCLUDED.value, notes = EXCLUDED.notes, updated_at = NOW()
+		RETURNING `+taskEntryColumns,
+		te.ID, te.DailyEntryID, te.TaskID, te.Completed, valueJSON, te.Notes, te.TaskRevision), te)
+}
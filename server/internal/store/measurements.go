@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// Values that were not measured are NULL
+const measurementColumns = `m.id, m.challenge_id, m.day_number, m.date,
+	m.weight, m.chest, m.waist, m.hips, m.arms, m.thighs, m.created_at, m.updated_at`
+
+func scanMeasurement(row scanner, m *models.Measurement) error {
+	return row.Scan(&m.ID, &m.ChallengeID, &m.DayNumber, &m.Date, &m.Weight, &m.Chest, &m.Waist,
+		&m.Hips, &m.Arms, &m.Thighs, &m.CreatedAt, &m.UpdatedAt)
+}
+
+// ListMeasurements implements MeasurementStore
+func (p *Postgres) ListMeasurements(ctx context.Context, challengeID uuid.UUID) ([]models.Measurement, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+measurementColumns+`
+		FROM measurements m
+		WHERE m.challenge_id = $1
+		ORDER BY m.date ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	measurements := []models.Measurement{}
+	for rows.Next() {
+		var m models.Measurement
+		if err := scanMeasurement(rows, &m); err != nil {
+			return nil, err
+		}
+		measurements = append(measurements, m)
+	}
+
+	return measurements, rows.Err()
+}
+
+// GetMeasurement implements MeasurementStore
+func (p *Postgres) GetMeasurement(ctx context.Context, userID, measurementID uuid.UUID) (*models.Measurement, error) {
+	var m models.Measurement
+	err := scanMeasurement(p.db.QueryRow(ctx, `
+		SELECT `+measurementColumns+`
+		FROM measurements m
+		JOIN challenges c ON m.challenge_id = c.id
+		WHERE m.id = $1 AND c.user_id = $2
+	`, measurementID, userID), &m)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &m, nil
+}
+
+// CreateMeasurement implements MeasurementStore
+func (p *Postgres) CreateMeasurement(ctx context.Context, m *models.Measurement) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	return scanMeasurement(p.db.QueryRow(ctx, `
+		INSERT INTO measurements AS m
+		(id, challenge_id, day_number, date, weight, chest, waist,
+		hips, arms, thighs, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
+		RETURNING `+measurementColumns,
+		m.ID, m.ChallengeID, m.DayNumber, m.Date, m.Weight, m.Chest, m.Waist,
+		m.Hips, m.Arms, m.Thighs), m)
+}
+
+// UpdateMeasurement implements MeasurementStore
+func (p *Postgres) UpdateMeasurement(ctx context.Context, m *models.Measurement) error {
+	err := scanMeasurement(p.db.QueryRow(ctx, `
+		UPDATE measurements AS m
+		SET day_number = $1, date = $2, weight = $3, chest = $4, waist = $5,
+		hips = $6, arms = $7, thighs = $8, updated_at = NOW()
+		WHERE m.id = $9
+		RETURNING `+measurementColumns,
+		m.DayNumber, m.Date, m.Weight, m.Chest, m.Waist, m.Hips, m.Arms, m.Thighs, m.ID), m)
+	return notFound(err)
+}
+
+// DeleteMeasurement implements MeasurementStore
+func (p *Postgres) DeleteMeasurement(ctx context.Context, measurementID uuid.UUID) error {
+	return requireRow(p.db.Exec(ctx, "DELETE FROM measurements WHERE id = $1", measurementID))
+}
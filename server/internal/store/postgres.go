@@ -0,0 +1,209 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// scanner is satisfied by both pgx.Row and pgx.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// Postgres implements Store on top of a pgx connection pool
+type Postgres struct {
+	db querier
+}
+
+var _ Store = (*Postgres)(nil)
+
+// NewPostgres creates a Store backed by pool
+func NewPostgres(pool *pgxpool.Pool) *Postgres {
+	return &Postgres{db: pool}
+}
+
+// WithTx implements Store. Calling it on a Store that is already inside a
+// transaction opens a savepoint.
+func (p *Postgres) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		return fn(tx)
+	})
+}
+
+func (p *Postgres) inTx(ctx context.Context, fn func(tx *Postgres) error) error {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	if err := fn(&Postgres{db: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// applyOrder renumbers the rows of table whose IDs are listed in ids from 1,
+// in list order. locked must select, and lock, the IDs of every row that
+// belongs to the parent being reordered; ErrOrderMismatch is returned unless
+// ids lists each of them exactly once. The order constraint, which is
+// deferrable, is deferred to the end of the transaction so rows can swap
+// places. It must run inside a transaction.
+func (p *Postgres) applyOrder(ctx context.Context, table, constraint, locked string, parentID uuid.UUID, ids []uuid.UUID) error {
+	rows, err := p.db.Query(ctx, locked, parentID)
+	if err != nil {
+		return err
+	}
+	current, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		listed[id] = true
+	}
+	if len(ids) != len(current) || len(listed) != len(ids) {
+		return ErrOrderMismatch
+	}
+	for _, id := range current {
+		if !listed[id] {
+			return ErrOrderMismatch
+		}
+	}
+
+	if _, err := p.db.Exec(ctx, "SET CONSTRAINTS "+constraint+" DEFERRED"); err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec(ctx, `
+		UPDATE `+table+` AS item
+		SET order_index = o.position, updated_at = NOW()
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE item.id = o.id AND item.order_index <> o.position
+	`, ids)
+	return err
+}
+
+// moveItem moves the row id of table under the parent parentID, at position
+// order, closing the gap it leaves behind and making room where it lands. An
+// order that is not positive or lies past the end appends the row. Only rows
+// matching filter take part in the order. It must run inside a transaction.
+func (p *Postgres) moveItem(ctx context.Context, table, parentColumn, constraint, filter string, id, parentID uuid.UUID, order int) error {
+	var fromID uuid.UUID
+	var fromOrder int
+	err := p.db.QueryRow(ctx, `
+		SELECT `+parentColumn+`, order_index FROM `+table+` WHERE id = $1 AND `+filter+` FOR UPDATE
+	`, id).Scan(&fromID, &fromOrder)
+	if err != nil {
+		return notFound(err)
+	}
+
+	if _, err := p.db.Exec(ctx, "SET CONSTRAINTS "+constraint+" DEFERRED"); err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec(ctx, `
+		UPDATE `+table+`
+		SET order_index = order_index - 1
+		WHERE `+parentColumn+` = $1 AND order_index > $2 AND `+filter,
+		fromID, fromOrder)
+	if err != nil {
+		return err
+	}
+
+	var siblings int
+	err = p.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM `+table+` WHERE `+parentColumn+` = $1 AND id <> $2 AND `+filter,
+		parentID, id).Scan(&siblings)
+	if err != nil {
+		return err
+	}
+	if order <= 0 || order > siblings+1 {
+		order = siblings + 1
+	}
+
+	_, err = p.db.Exec(ctx, `
+		UPDATE `+table+`
+		SET order_index = order_index + 1
+		WHERE `+parentColumn+` = $1 AND order_index >= $2 AND id <> $3 AND `+filter,
+		parentID, order, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.db.Exec(ctx, `
+		UPDATE `+table+` SET `+parentColumn+` = $1, order_index = $2, updated_at = NOW() WHERE id = $3
+	`, parentID, order, id)
+	return err
+}
+
+// checkNoForeignEntries returns ErrHasEntries when the EXISTS query finds
+// task entries that a move would leave in a challenge other than the one
+// their task lands in. Those entries would keep the task, and its revisions,
+// from being deleted with its new challenge.
+func (p *Postgres) checkNoForeignEntries(ctx context.Context, query string, args ...any) error {
+	var found bool
+	if err := p.db.QueryRow(ctx, query, args...).Scan(&found); err != nil {
+		return err
+	}
+	if found {
+		return ErrHasEntries
+	}
+	return nil
+}
+
+// notFound maps pgx.ErrNoRows to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// requireRow returns ErrNotFound when a write touched no rows
+func requireRow(tag pgconn.CommandTag, err error) error {
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// timeValue dereferences a nullable DATE/TIMESTAMP column
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,188 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/migrate"
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testStore connects to the database at TEST_DATABASE_URL and migrates it,
+// skipping the test when none is configured. Each test gets a user of its
+// own, deleted with everything it owns afterwards.
+func testStore(t *testing.T) (*Postgres, *models.User) {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	migrator, err := migrate.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPostgres(pool)
+	clerkID := "test_" + uuid.NewString()
+	user, err := p.UpsertUser(ctx, clerkID, clerkID+"@example.com", "Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := p.DeleteUser(context.Background(), clerkID); err != nil {
+			t.Errorf("delete test user: %v", err)
+		}
+	})
+	return p, user
+}
+
+// newSection creates a challenge for user with a single section
+func newSection(t *testing.T, p *Postgres, user *models.User) (*models.Challenge, *models.Section) {
+	t.Helper()
+	ctx := context.Background()
+
+	challenge := &models.Challenge{
+		ID:           uuid.New(),
+		UserID:       user.ID,
+		Name:         "Challenge",
+		StartDate:    time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		DurationDays: 10,
+		CurrentDay:   1,
+		Status:       "active",
+	}
+	if err := p.CreateChallenge(ctx, challenge); err != nil {
+		t.Fatal(err)
+	}
+
+	section := &models.Section{ChallengeID: challenge.ID, Name: "Section"}
+	if err := p.CreateSection(ctx, section); err != nil {
+		t.Fatal(err)
+	}
+	return challenge, section
+}
+
+func newTask(t *testing.T, p *Postgres, section *models.Section) *models.Task {
+	t.Helper()
+
+	task := &models.Task{SectionID: section.ID, Name: "Task", TaskType: "boolean"}
+	if err := p.CreateTask(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// record completes task on day 1 of challenge
+func record(t *testing.T, p *Postgres, challenge *models.Challenge, task *models.Task) {
+	t.Helper()
+	ctx := context.Background()
+
+	entry := &models.DailyEntry{ChallengeID: challenge.ID, DayNumber: 1, Date: challenge.StartDate}
+	if err := p.CreateEntry(ctx, entry); err != nil {
+		t.Fatal(err)
+	}
+	te := &models.TaskEntry{DailyEntryID: entry.ID, TaskID: task.ID, Completed: true}
+	if err := p.UpsertTaskEntry(ctx, te); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveAcrossChallenges(t *testing.T) {
+	p, user := testStore(t)
+	ctx := context.Background()
+
+	t.Run("task without entries", func(t *testing.T) {
+		_, from := newSection(t, p, user)
+		target, to := newSection(t, p, user)
+		task := newTask(t, p, from)
+
+		if err := p.MoveTask(ctx, task.ID, to.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.DeleteChallenge(ctx, user.ID, target.ID); err != nil {
+			t.Errorf("delete target challenge: %v", err)
+		}
+	})
+
+	t.Run("task with entries", func(t *testing.T) {
+		source, from := newSection(t, p, user)
+		target, to := newSection(t, p, user)
+		task := newTask(t, p, from)
+		record(t, p, source, task)
+
+		if err := p.MoveTask(ctx, task.ID, to.ID, 0); !errors.Is(err, ErrHasEntries) {
+			t.Fatalf("MoveTask = %v, want ErrHasEntries", err)
+		}
+		for _, c := range []*models.Challenge{target, source} {
+			if err := p.DeleteChallenge(ctx, user.ID, c.ID); err != nil {
+				t.Errorf("delete challenge: %v", err)
+			}
+		}
+	})
+
+	t.Run("task with entries within its challenge", func(t *testing.T) {
+		source, from := newSection(t, p, user)
+		to := &models.Section{ChallengeID: source.ID, Name: "Other section"}
+		if err := p.CreateSection(ctx, to); err != nil {
+			t.Fatal(err)
+		}
+		task := newTask(t, p, from)
+		record(t, p, source, task)
+
+		if err := p.MoveTask(ctx, task.ID, to.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.DeleteChallenge(ctx, user.ID, source.ID); err != nil {
+			t.Errorf("delete challenge: %v", err)
+		}
+	})
+
+	t.Run("section without entries", func(t *testing.T) {
+		_, section := newSection(t, p, user)
+		target, _ := newSection(t, p, user)
+		newTask(t, p, section)
+
+		if err := p.MoveSection(ctx, section.ID, target.ID, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.DeleteChallenge(ctx, user.ID, target.ID); err != nil {
+			t.Errorf("delete target challenge: %v", err)
+		}
+	})
+
+	t.Run("section with an archived task with entries", func(t *testing.T) {
+		source, section := newSection(t, p, user)
+		target, _ := newSection(t, p, user)
+		task := newTask(t, p, section)
+		record(t, p, source, task)
+		if err := p.ArchiveTask(ctx, task.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := p.MoveSection(ctx, section.ID, target.ID, 0); !errors.Is(err, ErrHasEntries) {
+			t.Fatalf("MoveSection = %v, want ErrHasEntries", err)
+		}
+		for _, c := range []*models.Challenge{target, source} {
+			if err := p.DeleteChallenge(ctx, user.ID, c.ID); err != nil {
+				t.Errorf("delete challenge: %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+const sectionColumns = `id, challenge_id, name, COALESCE(description, ''), order_index, created_at, updated_at`
+
+func scanSection(row scanner, s *models.Section) error {
+	return row.Scan(&s.ID, &s.ChallengeID, &s.Name, &s.Description, &s.Order, &s.CreatedAt, &s.UpdatedAt)
+}
+
+// ListSections implements SectionStore
+func (p *Postgres) ListSections(ctx context.Context, challengeID uuid.UUID) ([]models.Section, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+sectionColumns+`
+		FROM sections
+		WHERE challenge_id = $1 AND archived_at IS NULL
+		ORDER BY order_index ASC
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sections := []models.Section{}
+	for rows.Next() {
+		var s models.Section
+		if err := scanSection(rows, &s); err != nil {
+			return nil, err
+		}
+		sections = append(sections, s)
+	}
+
+	return sections, rows.Err()
+}
+
+// GetSection implements SectionStore
+func (p *Postgres) GetSection(ctx context.Context, userID, sectionID uuid.UUID) (*models.Section, error) {
+	var s models.Section
+	err := scanSection(p.db.QueryRow(ctx, `
+		SELECT s.id, s.challenge_id, s.name, COALESCE(s.description, ''), s.order_index, s.created_at, s.updated_at
+		FROM sections s
+		JOIN challenges c ON s.challenge_id = c.id
+		WHERE s.id = $1 AND c.user_id = $2 AND s.archived_at IS NULL
+	`, sectionID, userID), &s)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &s, nil
+}
+
+// sectionOrderConstraint keeps the orders of a challenge's live sections
+// unique
+const sectionOrderConstraint = "sections_challenge_id_order_index_excl"
+
+// CreateSection implements SectionStore. A section inserted at a given order
+// moves the sections from there on down by one.
+func (p *Postgres) CreateSection(ctx context.Context, s *models.Section) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+
+	return scanSection(p.db.QueryRow(ctx, `
+		WITH shifted AS (
+			UPDATE sections
+			SET order_index = order_index + 1
+			WHERE challenge_id = $2 AND $5 > 0 AND order_index >= $5 AND archived_at IS NULL
+		)
+		INSERT INTO sections (id, challenge_id, name, description, order_index, created_at, updated_at)
+		VALUES ($1, $2, $3, $4,
+			CASE WHEN $5 > 0 THEN $5 ELSE (
+				SELECT COALESCE(MAX(order_index), 0) + 1 FROM sections WHERE challenge_id = $2 AND archived_at IS NULL
+			) END,
+			NOW(), NOW())
+		RETURNING `+sectionColumns,
+		s.ID, s.ChallengeID, s.Name, s.Description, s.Order), s)
+}
+
+// UpdateSection implements SectionStore. Only the name and description change.
+func (p *Postgres) UpdateSection(ctx context.Context, s *models.Section) error {
+	err := scanSection(p.db.QueryRow(ctx, `
+		UPDATE sections
+		SET name = $1, description = $2, updated_at = NOW()
+		WHERE id = $3 AND archived_at IS NULL
+		RETURNING `+sectionColumns,
+		s.Name, s.Description, s.ID), s)
+	return notFound(err)
+}
+
+// ArchiveSection implements SectionStore
+func (p *Postgres) ArchiveSection(ctx context.Context, sectionID uuid.UUID) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var challengeID uuid.UUID
+		var currentOrder int
+		err := tx.db.QueryRow(ctx, `
+			UPDATE sections
+			SET archived_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND archived_at IS NULL
+			RETURNING challenge_id, order_index
+		`, sectionID).Scan(&challengeID, &currentOrder)
+		if err != nil {
+			return notFound(err)
+		}
+
+		_, err = tx.db.Exec(ctx, `
+			UPDATE tasks
+			SET archived_at = NOW(), updated_at = NOW()
+			WHERE section_id = $1 AND archived_at IS NULL
+		`, sectionID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(ctx, `
+			UPDATE sections
+			SET order_index = order_index - 1
+			WHERE challenge_id = $1 AND order_index > $2 AND archived_at IS NULL
+		`, challengeID, currentOrder)
+		return err
+	})
+}
+
+// ReorderSection implements SectionStore by shifting the sections between the
+// old and new positions
+func (p *Postgres) ReorderSection(ctx context.Context, sectionID uuid.UUID, order int) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var challengeID uuid.UUID
+		var currentOrder int
+		err := tx.db.QueryRow(ctx, `
+			SELECT challenge_id, order_index FROM sections WHERE id = $1 AND archived_at IS NULL FOR UPDATE
+		`, sectionID).Scan(&challengeID, &currentOrder)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if _, err := tx.db.Exec(ctx, "SET CONSTRAINTS "+sectionOrderConstraint+" DEFERRED"); err != nil {
+			return err
+		}
+
+		if order < currentOrder {
+			// Moving up (smaller order number)
+			_, err = tx.db.Exec(ctx, `
+				UPDATE sections
+				SET order_index = order_index + 1
+				WHERE challenge_id = $1 AND order_index >= $2 AND order_index < $3 AND archived_at IS NULL
+			`, challengeID, order, currentOrder)
+		} else if order > currentOrder {
+			// Moving down (larger order number)
+			_, err = tx.db.Exec(ctx, `
+				UPDATE sections
+				SET order_index = order_index - 1
+				WHERE challenge_id = $1 AND order_index > $2 AND order_index <= $3 AND archived_at IS NULL
+			`, challengeID, currentOrder, order)
+		} else {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(ctx, `UPDATE sections SET order_index = $1, updated_at = NOW() WHERE id = $2`, order, sectionID)
+		return err
+	})
+}
+
+// SetSectionOrder implements SectionStore
+func (p *Postgres) SetSectionOrder(ctx context.Context, challengeID uuid.UUID, ids []uuid.UUID) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		return tx.applyOrder(ctx, "sections", sectionOrderConstraint, `
+			SELECT id FROM sections WHERE challenge_id = $1 AND archived_at IS NULL FOR UPDATE
+		`, challengeID, ids)
+	})
+}
+
+// MoveSection implements SectionStore, renumbering the sections of both
+// challenges
+func (p *Postgres) MoveSection(ctx context.Context, sectionID, challengeID uuid.UUID, order int) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		err := tx.checkNoForeignEntries(ctx, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM task_entries te
+				JOIN tasks t ON te.task_id = t.id
+				JOIN daily_entries d ON te.daily_entry_id = d.id
+				WHERE t.section_id = $1 AND d.challenge_id <> $2
+			)
+		`, sectionID, challengeID)
+		if err != nil {
+			return err
+		}
+
+		return tx.moveItem(ctx, "sections", "challenge_id", sectionOrderConstraint, "archived_at IS NULL", sectionID, challengeID, order)
+	})
+}
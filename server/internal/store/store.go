@@ -0,0 +1,186 @@
+// Package store defines the persistence interfaces used by the HTTP handlers
+// and a PostgreSQL implementation of them built on pgx.
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// ErrNotFound is returned when a record doesn't exist or isn't owned by the
+// requesting user. The two cases are deliberately indistinguishable.
+var ErrNotFound = errors.New("not found")
+
+// ErrOrderMismatch is returned when a new order does not list every item
+// being ordered exactly once
+var ErrOrderMismatch = errors.New("order must list every item exactly once")
+
+// ErrHasEntries is returned when a task or section can't be moved to another
+// challenge because entries were already recorded for it
+var ErrHasEntries = errors.New("entries were recorded for it")
+
+// ChallengeStore persists challenges. Lookups are scoped to the owning user.
+type ChallengeStore interface {
+	ListChallenges(ctx context.Context, userID uuid.UUID) ([]models.Challenge, error)
+	GetChallenge(ctx context.Context, userID, challengeID uuid.UUID) (*models.Challenge, error)
+	CreateChallenge(ctx context.Context, challenge *models.Challenge) error
+	// UpdateChallenge saves the settings of a challenge. The start date,
+	// current day and status are not touched; see ResetChallenge,
+	// SetChallengeDay and SetChallengeStatus.
+	UpdateChallenge(ctx context.Context, challenge *models.Challenge) error
+	DeleteChallenge(ctx context.Context, userID, challengeID uuid.UUID) error
+	// ResetChallenge closes the running attempt with reason and starts a new
+	// one from day 1 on startDate
+	ResetChallenge(ctx context.Context, userID, challengeID uuid.UUID, reason string, startDate time.Time) error
+	// SetChallengeDay moves current_day to day
+	SetChallengeDay(ctx context.Context, challengeID uuid.UUID, day int) error
+	// ClaimDueChallenge locks an active challenge whose current day is already
+	// over, ignoring the ones in skip. It returns ErrNotFound when none is due.
+	// It must run inside a transaction, which holds the lock.
+	ClaimDueChallenge(ctx context.Context, skip []uuid.UUID) (*models.Challenge, error)
+	// SetChallengeStatus moves the challenge to active, completed or failed
+	SetChallengeStatus(ctx context.Context, challengeID uuid.UUID, status string) error
+}
+
+// SectionStore persists the sections of a challenge. Archived sections are
+// left out.
+type SectionStore interface {
+	ListSections(ctx context.Context, challengeID uuid.UUID) ([]models.Section, error)
+	GetSection(ctx context.Context, userID, sectionID uuid.UUID) (*models.Section, error)
+	// CreateSection appends the section when its Order is not positive
+	CreateSection(ctx context.Context, section *models.Section) error
+	UpdateSection(ctx context.Context, section *models.Section) error
+	// ArchiveSection hides the section and archives its tasks, keeping their
+	// entries
+	ArchiveSection(ctx context.Context, sectionID uuid.UUID) error
+	ReorderSection(ctx context.Context, sectionID uuid.UUID, order int) error
+	// SetSectionOrder puts every section of a challenge in the order of ids
+	SetSectionOrder(ctx context.Context, challengeID uuid.UUID, ids []uuid.UUID) error
+	// MoveSection moves a section with its tasks to order within
+	// challengeID, appending it when order is not positive. It returns
+	// ErrHasEntries when any of its tasks, archived ones included, has
+	// entries recorded in another challenge.
+	MoveSection(ctx context.Context, sectionID, challengeID uuid.UUID, order int) error
+}
+
+// TaskStore persists the tasks of a section. Archived tasks are left out of
+// everything but ListTaskRevisions.
+type TaskStore interface {
+	ListTasks(ctx context.Context, sectionID uuid.UUID) ([]models.Task, error)
+	// ListChallengeTasks returns every task of a challenge, ordered by section then task
+	ListChallengeTasks(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error)
+	// ListTaskRevisions returns every revision of every task of a challenge,
+	// including archived tasks and tasks moved away that have entries in it,
+	// ordered by section, task then revision
+	ListTaskRevisions(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error)
+	GetTask(ctx context.Context, userID, taskID uuid.UUID) (*models.Task, error)
+	// CreateTask appends the task when its Order is not positive
+	CreateTask(ctx context.Context, task *models.Task) error
+	UpdateTask(ctx context.Context, task *models.Task) error
+	// ArchiveTask hides the task while keeping its entries
+	ArchiveTask(ctx context.Context, taskID uuid.UUID) error
+	ReorderTask(ctx context.Context, taskID uuid.UUID, order int) error
+	// SetTaskOrder puts every task of a section in the order of ids
+	SetTaskOrder(ctx context.Context, sectionID uuid.UUID, ids []uuid.UUID) error
+	// MoveTask moves a task to order within sectionID, appending it when
+	// order is not positive. Its entries stay with it, so it returns
+	// ErrHasEntries when the task has entries in another challenge.
+	MoveTask(ctx context.Context, taskID, sectionID uuid.UUID, order int) error
+}
+
+// UserStore persists users. Users are keyed by their Clerk ID and kept in
+// sync by the Clerk webhooks and the auth middleware.
+type UserStore interface {
+	GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error)
+	GetUserByClerkID(ctx context.Context, clerkID string) (*models.User, error)
+	// UpsertUser creates the user for a Clerk ID, or refreshes the email and
+	// name of an existing one
+	UpsertUser(ctx context.Context, clerkID, email, name string) (*models.User, error)
+	// DeleteUser removes the user for a Clerk ID along with everything they
+	// own. Deleting a missing user succeeds.
+	DeleteUser(ctx context.Context, clerkID string) error
+	UpdateUserTimezone(ctx context.Context, userID uuid.UUID, timezone string) (*models.User, error)
+}
+
+// AttemptStore reads the attempt history of a challenge
+type AttemptStore interface {
+	// ListAttempts returns every attempt, oldest first, with entry counts
+	ListAttempts(ctx context.Context, challengeID uuid.UUID) ([]models.Attempt, error)
+	// CurrentAttempt returns the newest attempt, running or not
+	CurrentAttempt(ctx context.Context, challengeID uuid.UUID) (*models.Attempt, error)
+}
+
+// EntryStore persists daily entries and the task entries recorded on them.
+// Daily entries are scoped to the challenge's current attempt.
+type EntryStore interface {
+	ListEntries(ctx context.Context, challengeID uuid.UUID) ([]models.DailyEntry, error)
+	GetEntry(ctx context.Context, challengeID uuid.UUID, dayNumber int) (*models.DailyEntry, error)
+	CreateEntry(ctx context.Context, entry *models.DailyEntry) error
+	UpdateEntry(ctx context.Context, entry *models.DailyEntry) error
+	ListTaskEntries(ctx context.Context, dailyEntryID uuid.UUID) ([]models.TaskEntry, error)
+	// ListAttemptTaskEntries returns the task entries of every daily entry in
+	// the challenge's current attempt
+	ListAttemptTaskEntries(ctx context.Context, challengeID uuid.UUID) ([]models.TaskEntry, error)
+	// ListTaskCompletions returns, per task, the day numbers of the current
+	// attempt on which the task was completed
+	ListTaskCompletions(ctx context.Context, challengeID uuid.UUID) (map[uuid.UUID][]int, error)
+	// UpsertTaskEntry inserts or replaces the entry for (daily entry, task)
+	UpsertTaskEntry(ctx context.Context, taskEntry *models.TaskEntry) error
+}
+
+// AttachmentStore persists the files attached to daily entries and task
+// entries. The files themselves live in a blob store.
+type AttachmentStore interface {
+	// ListEntryAttachments returns the attachments of a daily entry and of its
+	// task entries, in order
+	ListEntryAttachments(ctx context.Context, dailyEntryID uuid.UUID) ([]models.Attachment, error)
+	// ListChallengeAttachments returns the attachments of every attempt of a
+	// challenge, by date and then order
+	ListChallengeAttachments(ctx context.Context, challengeID uuid.UUID) ([]models.Attachment, error)
+	// ListUserAttachments returns the attachments of every challenge of a
+	// user, in no particular order
+	ListUserAttachments(ctx context.Context, userID uuid.UUID) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, userID, attachmentID uuid.UUID) (*models.Attachment, error)
+	// CreateAttachment appends the attachment to its daily or task entry
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	DeleteAttachment(ctx context.Context, attachmentID uuid.UUID) error
+}
+
+// MeasurementStore persists body measurements
+type MeasurementStore interface {
+	ListMeasurements(ctx context.Context, challengeID uuid.UUID) ([]models.Measurement, error)
+	GetMeasurement(ctx context.Context, userID, measurementID uuid.UUID) (*models.Measurement, error)
+	CreateMeasurement(ctx context.Context, measurement *models.Measurement) error
+	UpdateMeasurement(ctx context.Context, measurement *models.Measurement) error
+	DeleteMeasurement(ctx context.Context, measurementID uuid.UUID) error
+}
+
+// TemplateStore persists the private templates users save from their
+// challenges. Built-in templates are not stored.
+type TemplateStore interface {
+	ListTemplates(ctx context.Context, userID uuid.UUID) ([]models.Template, error)
+	GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*models.Template, error)
+	CreateTemplate(ctx context.Context, template *models.Template) error
+	DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error
+}
+
+// Store groups every store and can run a function inside one transaction
+type Store interface {
+	UserStore
+	ChallengeStore
+	AttemptStore
+	SectionStore
+	TaskStore
+	EntryStore
+	AttachmentStore
+	MeasurementStore
+	TemplateStore
+
+	// WithTx runs fn against a Store bound to a single transaction. The
+	// transaction commits when fn returns nil and rolls back otherwise.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+const taskColumns = `t.id, t.section_id, t.name, COALESCE(t.description, ''), t.task_type, t.required,
+	t.restart_on_fail, t.strikes_enabled, COALESCE(t.strikes_limit, 0), t.config, t.schedule, t.order_index,
+	t.revision, t.archived_at, t.created_at, t.updated_at`
+
+// revisionColumns reads a task_revisions row r joined with its task t in the
+// column order of taskColumns
+const revisionColumns = `r.task_id, t.section_id, r.name, COALESCE(r.description, ''), r.task_type, r.required,
+	r.restart_on_fail, r.strikes_enabled, COALESCE(r.strikes_limit, 0), r.config, r.schedule, t.order_index,
+	r.revision, t.archived_at, r.created_at, r.created_at`
+
+// snapshotRevision records the task rows returned by the common table
+// expression t as revisions, skipping revisions that are already recorded
+const snapshotRevision = `
+	INSERT INTO task_revisions (task_id, revision, name, description, task_type, required, restart_on_fail,
+	                            strikes_enabled, strikes_limit, config, schedule, created_at)
+	SELECT id, revision, name, description, task_type, required, restart_on_fail,
+	       strikes_enabled, strikes_limit, config, schedule, updated_at
+	FROM t
+	ON CONFLICT (task_id, revision) DO NOTHING`
+
+func scanTask(row scanner, t *models.Task) error {
+	var configJSON, scheduleJSON []byte
+	if err := row.Scan(&t.ID, &t.SectionID, &t.Name, &t.Description, &t.TaskType, &t.Required,
+		&t.RestartOnFail, &t.StrikesEnabled, &t.StrikesLimit, &configJSON, &scheduleJSON,
+		&t.Order, &t.Revision, &t.ArchivedAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+
+	t.Config = models.TaskConfig{}
+	if err := json.Unmarshal(configJSON, &t.Config); err != nil {
+		return fmt.Errorf("decode task config: %w", err)
+	}
+
+	t.Schedule = models.TaskSchedule{}
+	if err := json.Unmarshal(scheduleJSON, &t.Schedule); err != nil {
+		return fmt.Errorf("decode task schedule: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) queryTasks(ctx context.Context, sql string, args ...any) ([]models.Task, error) {
+	rows, err := p.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		var t models.Task
+		if err := scanTask(rows, &t); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	return tasks, rows.Err()
+}
+
+// encodeTaskSettings marshals the JSONB columns of a task. An empty schedule
+// is stored as daily.
+func encodeTaskSettings(t *models.Task) (configJSON, scheduleJSON []byte, err error) {
+	if configJSON, err = json.Marshal(t.Config); err != nil {
+		return nil, nil, fmt.Errorf("encode task config: %w", err)
+	}
+
+	if t.Schedule.Kind == "" {
+		t.Schedule.Kind = "daily"
+	}
+	if scheduleJSON, err = json.Marshal(t.Schedule); err != nil {
+		return nil, nil, fmt.Errorf("encode task schedule: %w", err)
+	}
+
+	return configJSON, scheduleJSON, nil
+}
+
+// ListTasks implements TaskStore
+func (p *Postgres) ListTasks(ctx context.Context, sectionID uuid.UUID) ([]models.Task, error) {
+	return p.queryTasks(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks t
+		WHERE t.section_id = $1 AND t.archived_at IS NULL
+		ORDER BY t.order_index ASC
+	`, sectionID)
+}
+
+// ListChallengeTasks implements TaskStore
+func (p *Postgres) ListChallengeTasks(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error) {
+	return p.queryTasks(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks t
+		JOIN sections s ON t.section_id = s.id
+		WHERE s.challenge_id = $1 AND t.archived_at IS NULL
+		ORDER BY s.order_index ASC, t.order_index ASC
+	`, challengeID)
+}
+
+// ListTaskRevisions implements TaskStore
+func (p *Postgres) ListTaskRevisions(ctx context.Context, challengeID uuid.UUID) ([]models.Task, error) {
+	return p.queryTasks(ctx, `
+		SELECT `+revisionColumns+`
+		FROM task_revisions r
+		JOIN tasks t ON r.task_id = t.id
+		JOIN sections s ON t.section_id = s.id
+		WHERE s.challenge_id = $1 OR r.task_id IN (
+			SELECT te.task_id
+			FROM task_entries te
+			JOIN daily_entries d ON te.daily_entry_id = d.id
+			WHERE d.challenge_id = $1
+		)
+		ORDER BY s.order_index ASC, t.order_index ASC, r.revision ASC
+	`, challengeID)
+}
+
+// GetTask implements TaskStore
+func (p *Postgres) GetTask(ctx context.Context, userID, taskID uuid.UUID) (*models.Task, error) {
+	var t models.Task
+	err := scanTask(p.db.QueryRow(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks t
+		JOIN sections s ON t.section_id = s.id
+		JOIN challenges c ON s.challenge_id = c.id
+		WHERE t.id = $1 AND c.user_id = $2 AND t.archived_at IS NULL
+	`, taskID, userID), &t)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &t, nil
+}
+
+// taskOrderConstraint keeps the orders of a section's live tasks unique
+const taskOrderConstraint = "tasks_section_id_order_index_excl"
+
+// CreateTask implements TaskStore and records the task's first revision. A
+// task inserted at a given order moves the tasks from there on down by one.
+func (p *Postgres) CreateTask(ctx context.Context, t *models.Task) error {
+	configJSON, scheduleJSON, err := encodeTaskSettings(t)
+	if err != nil {
+		return err
+	}
+
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	return scanTask(p.db.QueryRow(ctx, `
+		WITH shifted AS (
+			UPDATE tasks
+			SET order_index = order_index + 1
+			WHERE section_id = $2 AND archived_at IS NULL AND $12 > 0 AND order_index >= $12
+		), t AS (
+			INSERT INTO tasks (id, section_id, name, description, task_type, required, restart_on_fail,
+			                   strikes_enabled, strikes_limit, config, schedule, order_index, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+				CASE WHEN $12 > 0 THEN $12 ELSE (
+					SELECT COALESCE(MAX(order_index), 0) + 1 FROM tasks WHERE section_id = $2 AND archived_at IS NULL
+				) END,
+				NOW(), NOW())
+			RETURNING *
+		), revision AS (`+snapshotRevision+`)
+		SELECT `+taskColumns+` FROM t`,
+		t.ID, t.SectionID, t.Name, t.Description, t.TaskType, t.Required, t.RestartOnFail,
+		t.StrikesEnabled, t.StrikesLimit, configJSON, scheduleJSON, t.Order), t)
+}
+
+// UpdateTask implements TaskStore. An update that changes the task's
+// definition bumps its revision and records it, so entries made before keep
+// pointing at the task as it was. The section and order are left unchanged.
+func (p *Postgres) UpdateTask(ctx context.Context, t *models.Task) error {
+	configJSON, scheduleJSON, err := encodeTaskSettings(t)
+	if err != nil {
+		return err
+	}
+
+	err = scanTask(p.db.QueryRow(ctx, `
+		WITH t AS (
+			UPDATE tasks
+			SET revision = revision + CASE
+			        WHEN (name, COALESCE(description, ''), task_type, required, restart_on_fail,
+			              strikes_enabled, COALESCE(strikes_limit, 0), config, schedule)
+			             IS DISTINCT FROM ($1, $2, $3, $4, $5, $6, $7, $8::jsonb, $9::jsonb)
+			        THEN 1 ELSE 0 END,
+			    name = $1, description = $2, task_type = $3, required = $4, restart_on_fail = $5,
+			    strikes_enabled = $6, strikes_limit = $7, config = $8, schedule = $9, updated_at = NOW()
+			WHERE id = $10 AND archived_at IS NULL
+			RETURNING *
+		), revision AS (`+snapshotRevision+`)
+		SELECT `+taskColumns+` FROM t`,
+		t.Name, t.Description, t.TaskType, t.Required, t.RestartOnFail,
+		t.StrikesEnabled, t.StrikesLimit, configJSON, scheduleJSON, t.ID), t)
+	return notFound(err)
+}
+
+// ArchiveTask implements TaskStore and closes the gap it leaves in the order
+func (p *Postgres) ArchiveTask(ctx context.Context, taskID uuid.UUID) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var sectionID uuid.UUID
+		var currentOrder int
+		err := tx.db.QueryRow(ctx, `
+			UPDATE tasks
+			SET archived_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND archived_at IS NULL
+			RETURNING section_id, order_index
+		`, taskID).Scan(&sectionID, &currentOrder)
+		if err != nil {
+			return notFound(err)
+		}
+
+		_, err = tx.db.Exec(ctx, `
+			UPDATE tasks
+			SET order_index = order_index - 1
+			WHERE section_id = $1 AND order_index > $2 AND archived_at IS NULL
+		`, sectionID, currentOrder)
+		return err
+	})
+}
+
+// ReorderTask implements TaskStore by shifting the tasks between the old and
+// new positions
+func (p *Postgres) ReorderTask(ctx context.Context, taskID uuid.UUID, order int) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		var sectionID uuid.UUID
+		var currentOrder int
+		err := tx.db.QueryRow(ctx, `
+			SELECT section_id, order_index FROM tasks WHERE id = $1 AND archived_at IS NULL FOR UPDATE
+		`, taskID).Scan(&sectionID, &currentOrder)
+		if err != nil {
+			return notFound(err)
+		}
+
+		if _, err := tx.db.Exec(ctx, "SET CONSTRAINTS "+taskOrderConstraint+" DEFERRED"); err != nil {
+			return err
+		}
+
+		if order < currentOrder {
+			// Moving up (smaller order number)
+			_, err = tx.db.Exec(ctx, `
+				UPDATE tasks
+				SET order_index = order_index + 1
+				WHERE section_id = $1 AND order_index >= $2 AND order_index < $3 AND archived_at IS NULL
+			`, sectionID, order, currentOrder)
+		} else if order > currentOrder {
+			// Moving down (larger order number)
+			_, err = tx.db.Exec(ctx, `
+				UPDATE tasks
+				SET order_index = order_index - 1
+				WHERE section_id = $1 AND order_index > $2 AND order_index <= $3 AND archived_at IS NULL
+			`, sectionID, currentOrder, order)
+		} else {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.db.Exec(ctx, `UPDATE tasks SET order_index = $1, updated_at = NOW() WHERE id = $2`, order, taskID)
+		return err
+	})
+}
+
+// SetTaskOrder implements TaskStore. Archived tasks keep their old order.
+func (p *Postgres) SetTaskOrder(ctx context.Context, sectionID uuid.UUID, ids []uuid.UUID) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		return tx.applyOrder(ctx, "tasks", taskOrderConstraint, `
+			SELECT id FROM tasks WHERE section_id = $1 AND archived_at IS NULL FOR UPDATE
+		`, sectionID, ids)
+	})
+}
+
+// MoveTask implements TaskStore, renumbering the live tasks of both sections
+func (p *Postgres) MoveTask(ctx context.Context, taskID, sectionID uuid.UUID, order int) error {
+	return p.inTx(ctx, func(tx *Postgres) error {
+		err := tx.checkNoForeignEntries(ctx, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM task_entries te
+				JOIN daily_entries d ON te.daily_entry_id = d.id
+				JOIN sections target ON target.id = $2
+				WHERE te.task_id = $1 AND d.challenge_id <> target.challenge_id
+			)
+		`, taskID, sectionID)
+		if err != nil {
+			return err
+		}
+
+		return tx.moveItem(ctx, "tasks", "section_id", taskOrderConstraint, "archived_at IS NULL", taskID, sectionID, order)
+	})
+}
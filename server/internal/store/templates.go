@@ -0,0 +1,90 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+const templateColumns = `id, user_id, name, COALESCE(description, ''), duration_days, sections, created_at, updated_at`
+
+func scanTemplate(row scanner, t *models.Template) error {
+	var sectionsJSON []byte
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Description, &t.DurationDays, &sectionsJSON,
+		&t.CreatedAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+
+	t.Sections = nil
+	if err := json.Unmarshal(sectionsJSON, &t.Sections); err != nil {
+		return fmt.Errorf("decode template sections: %w", err)
+	}
+
+	return nil
+}
+
+// ListTemplates implements TemplateStore
+func (p *Postgres) ListTemplates(ctx context.Context, userID uuid.UUID) ([]models.Template, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT `+templateColumns+`
+		FROM templates
+		WHERE user_id = $1
+		ORDER BY name ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.Template{}
+	for rows.Next() {
+		var t models.Template
+		if err := scanTemplate(rows, &t); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	return templates, rows.Err()
+}
+
+// GetTemplate implements TemplateStore
+func (p *Postgres) GetTemplate(ctx context.Context, userID, templateID uuid.UUID) (*models.Template, error) {
+	var t models.Template
+	err := scanTemplate(p.db.QueryRow(ctx, `
+		SELECT `+templateColumns+`
+		FROM templates
+		WHERE id = $1 AND user_id = $2
+	`, templateID, userID), &t)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &t, nil
+}
+
+// CreateTemplate implements TemplateStore
+func (p *Postgres) CreateTemplate(ctx context.Context, t *models.Template) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+
+	sectionsJSON, err := json.Marshal(t.Sections)
+	if err != nil {
+		return fmt.Errorf("encode template sections: %w", err)
+	}
+
+	return scanTemplate(p.db.QueryRow(ctx, `
+		INSERT INTO templates (id, user_id, name, description, duration_days, sections, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		RETURNING `+templateColumns,
+		t.ID, t.UserID, t.Name, t.Description, t.DurationDays, sectionsJSON), t)
+}
+
+// DeleteTemplate implements TemplateStore
+func (p *Postgres) DeleteTemplate(ctx context.Context, userID, templateID uuid.UUID) error {
+	return requireRow(p.db.Exec(ctx, "DELETE FROM templates WHERE id = $1 AND user_id = $2", templateID, userID))
+}
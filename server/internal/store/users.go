@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+const userColumns = `id, clerk_id, email, name, timezone, created_at, updated_at`
+
+func scanUser(row scanner, u *models.User) error {
+	return row.Scan(&u.ID, &u.ClerkID, &u.Email, &u.Name, &u.Timezone, &u.CreatedAt, &u.UpdatedAt)
+}
+
+// GetUser implements UserStore
+func (p *Postgres) GetUser(ctx context.Context, userID uuid.UUID) (*models.User, error) {
+	var u models.User
+	err := scanUser(p.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE id = $1
+	`, userID), &u)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &u, nil
+}
+
+// GetUserByClerkID implements UserStore
+func (p *Postgres) GetUserByClerkID(ctx context.Context, clerkID string) (*models.User, error) {
+	var u models.User
+	err := scanUser(p.db.QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE clerk_id = $1
+	`, clerkID), &u)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &u, nil
+}
+
+// UpsertUser implements UserStore
+func (p *Postgres) UpsertUser(ctx context.Context, clerkID, email, name string) (*models.User, error) {
+	var u models.User
+	err := scanUser(p.db.QueryRow(ctx, `
+		INSERT INTO users (clerk_id, email, name, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (clerk_id) DO UPDATE
+		SET email = EXCLUDED.email, name = EXCLUDED.name, updated_at = NOW()
+		RETURNING `+userColumns,
+		clerkID, email, name), &u)
+	if err != nil {
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+// DeleteUser implements UserStore. Challenges and everything beneath them are
+// removed by the ON DELETE CASCADE foreign keys.
+func (p *Postgres) DeleteUser(ctx context.Context, clerkID string) error {
+	_, err := p.db.Exec(ctx, "DELETE FROM users WHERE clerk_id = $1", clerkID)
+	return err
+}
+
+// UpdateUserTimezone implements UserStore
+func (p *Postgres) UpdateUserTimezone(ctx context.Context, userID uuid.UUID, timezone string) (*models.User, error) {
+	var u models.User
+	err := scanUser(p.db.QueryRow(ctx, `
+		UPDATE users
+		SET timezone = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING `+userColumns,
+		timezone, userID), &u)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &u, nil
+}
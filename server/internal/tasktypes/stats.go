@@ -0,0 +1,100 @@
+package tasktypes
+
+import (
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// NumberStats summarises the numbers recorded for a task or one of its fields
+type NumberStats struct {
+	Count   int     `json:"count"`
+	Total   float64 `json:"total"`
+	Average float64 `json:"average"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+func (n *NumberStats) add(v float64) {
+	if n.Count == 0 || v < n.Min {
+		n.Min = v
+	}
+	if n.Count == 0 || v > n.Max {
+		n.Max = v
+	}
+	n.Count++
+	n.Total += v
+	n.Average = n.Total / float64(n.Count)
+}
+
+// Stats summarises the entries recorded for one task. Only the fields that
+// fit the task's type are filled in.
+type Stats struct {
+	TaskID   uuid.UUID `json:"task_id"`
+	Name     string    `json:"name"`
+	TaskType string    `json:"task_type"`
+	Unit     string    `json:"unit,omitempty"`
+	Archived bool      `json:"archived,omitempty"`
+	// DueDays counts the days so far the task was due on, filled in by
+	// callers that know the schedule
+	DueDays   int `json:"due_days"`
+	Entries   int `json:"entries"`
+	Completed int `json:"completed"`
+	// Checked counts boolean values that were true
+	Checked int `json:"checked,omitempty"`
+	// Numbers covers number, duration, rating and counter values
+	Numbers *NumberStats `json:"numbers,omitempty"`
+	// Fields covers multi-number values, by label
+	Fields map[string]*NumberStats `json:"fields,omitempty"`
+	// Options counts how often each select option was picked
+	Options map[string]int `json:"options,omitempty"`
+	Photos  int            `json:"photos,omitempty"`
+}
+
+func (s *Stats) addNumber(v float64) {
+	if s.Numbers == nil {
+		s.Numbers = &NumberStats{}
+	}
+	s.Numbers.add(v)
+}
+
+func (s *Stats) addField(label string, v float64) {
+	if s.Fields == nil {
+		s.Fields = map[string]*NumberStats{}
+	}
+	if s.Fields[label] == nil {
+		s.Fields[label] = &NumberStats{}
+	}
+	s.Fields[label].add(v)
+}
+
+// Summarize builds the stats of task from its entries. Entries that carry
+// the task as it was when they were recorded are read against that revision.
+// Values that no longer fit, e.g. after the type was changed, are counted as
+// entries but otherwise skipped.
+func Summarize(task *models.Task, entries []models.TaskEntry) Stats {
+	stats := Stats{
+		TaskID:   task.ID,
+		Name:     task.Name,
+		TaskType: task.TaskType,
+		Unit:     task.Config.Unit,
+		Archived: task.ArchivedAt != nil,
+	}
+
+	for _, entry := range entries {
+		stats.Entries++
+		if entry.Completed {
+			stats.Completed++
+		}
+
+		rev := task
+		if entry.Task != nil {
+			rev = entry.Task
+		}
+		t, known := types[rev.TaskType]
+		if known && entry.Value != nil && t.ValidateValue(rev.Config, entry.Value) == nil {
+			t.Summarize(rev.Config, entry.Value, &stats)
+		}
+	}
+
+	return stats
+}
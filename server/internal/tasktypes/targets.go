@@ -0,0 +1,113 @@
+package tasktypes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+var comparators = map[string]func(value, target float64) bool{
+	"gte": func(v, t float64) bool { return v >= t },
+	"gt":  func(v, t float64) bool { return v > t },
+	"lte": func(v, t float64) bool { return v <= t },
+	"lt":  func(v, t float64) bool { return v < t },
+	"eq":  func(v, t float64) bool { return v == t },
+}
+
+// score reduces a value of a numeric task type to the number its target is
+// compared with: the value itself, or the sum of a multi-number's fields
+func score(value any) (n float64, ok bool) {
+	if fields, isObject := value.(map[string]any); isObject {
+		for _, v := range fields {
+			if f, isNumber := v.(float64); isNumber {
+				n += f
+			}
+		}
+		return n, true
+	}
+
+	n, ok = value.(float64)
+	return n, ok
+}
+
+// equalityTarget reports whether targets of the type compare values for
+// equality
+func equalityTarget(taskType string) bool {
+	return taskType == "boolean" || taskType == "select"
+}
+
+// validateTarget checks the target and default of a task's config
+func validateTarget(task *models.Task) error {
+	cfg := task.Config
+
+	if cfg.Default != nil {
+		if err := ValidateValue(task, cfg.Default); err != nil {
+			return fmt.Errorf("default %w", err)
+		}
+	}
+
+	target := cfg.Target
+	if target == nil {
+		return nil
+	}
+
+	switch {
+	case equalityTarget(task.TaskType):
+		if target.Comparator != "eq" {
+			return errors.New("target comparator must be eq")
+		}
+		if target.Value == nil {
+			return errors.New("target value is required")
+		}
+		if err := ValidateValue(task, target.Value); err != nil {
+			return fmt.Errorf("target value %w", err)
+		}
+	case isNumeric(task.TaskType):
+		if _, ok := comparators[target.Comparator]; !ok {
+			return errors.New("target comparator must be one of gte, gt, lte, lt or eq")
+		}
+		if _, err := number(target.Value); err != nil {
+			return fmt.Errorf("target value %w", err)
+		}
+	default:
+		return fmt.Errorf("%s tasks do not support targets", task.TaskType)
+	}
+
+	return nil
+}
+
+// isNumeric reports whether targets of the type compare numbers
+func isNumeric(taskType string) bool {
+	switch taskType {
+	case "number", "duration", "rating", "counter", "multi_number":
+		return true
+	}
+	return false
+}
+
+// MeetsTarget reports whether value reaches the task's target. ok is false
+// when the task has no target or nothing was recorded, in which case the
+// completion sent by the client stands.
+func MeetsTarget(task *models.Task, value any) (met, ok bool) {
+	target := task.Config.Target
+	if target == nil || value == nil {
+		return false, false
+	}
+
+	if equalityTarget(task.TaskType) {
+		return value == target.Value, true
+	}
+
+	if !isNumeric(task.TaskType) {
+		return false, false
+	}
+
+	n, ok := score(value)
+	want, isNumber := target.Value.(float64)
+	compare, known := comparators[target.Comparator]
+	if !ok || !isNumber || !known {
+		return false, false
+	}
+	return compare(n, want), true
+}
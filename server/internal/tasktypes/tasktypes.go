@@ -0,0 +1,92 @@
+// Package tasktypes knows the task types a challenge can use: which settings
+// each accepts and which values can be recorded against it.
+package tasktypes
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// DefaultMaxTextLength caps text values of tasks without a max_length
+const DefaultMaxTextLength = 2000
+
+// Type validates the configuration and the recorded values of one task type
+// and summarises what was recorded
+type Type interface {
+	// ValidateConfig checks the settings of a task of this type
+	ValidateConfig(cfg models.TaskConfig) error
+	// ValidateValue checks a value recorded for a task configured with cfg.
+	// Values arrive decoded from JSON, so numbers are float64.
+	ValidateValue(cfg models.TaskConfig, value any) error
+	// Summarize adds a recorded, valid value to stats
+	Summarize(cfg models.TaskConfig, value any, stats *Stats)
+}
+
+var types = map[string]Type{
+	"boolean":      booleanType{},
+	"number":       numberType{},
+	"text":         textType{},
+	"select":       selectType{},
+	"duration":     durationType{},
+	"rating":       ratingType{},
+	"counter":      counterType{},
+	"multi_number": multiNumberType{},
+	"photo":        photoType{},
+}
+
+// Names lists the known task types
+func Names() []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the task type called name
+func Lookup(name string) (Type, bool) {
+	t, ok := types[name]
+	return t, ok
+}
+
+// ValidateConfig checks a task's settings against its type, including its
+// target and default value
+func ValidateConfig(task *models.Task) error {
+	t, ok := types[task.TaskType]
+	if !ok {
+		return fmt.Errorf("unknown task type %q", task.TaskType)
+	}
+	if err := t.ValidateConfig(task.Config); err != nil {
+		return err
+	}
+	return validateTarget(task)
+}
+
+// AttachmentID returns the attachment a valid value of a photo task refers
+// to. It reports false for every other task type.
+func AttachmentID(task *models.Task, value any) (uuid.UUID, bool) {
+	if task.TaskType != "photo" {
+		return uuid.Nil, false
+	}
+	s, _ := value.(string)
+	id, err := uuid.Parse(s)
+	return id, err == nil
+}
+
+// ValidateValue checks a value recorded for task. A nil value means nothing
+// was recorded and is always accepted.
+func ValidateValue(task *models.Task, value any) error {
+	if value == nil {
+		return nil
+	}
+
+	t, ok := types[task.TaskType]
+	if !ok {
+		return fmt.Errorf("unknown task type %q", task.TaskType)
+	}
+	return t.ValidateValue(task.Config, value)
+}
@@ -0,0 +1,304 @@
+package tasktypes
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+func TestValidateValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    models.Task
+		value   any
+		wantErr bool
+	}{
+		{"nothing recorded", models.Task{TaskType: "number"}, nil, false},
+		{"unknown type", models.Task{TaskType: "colour"}, "red", true},
+
+		{"boolean true", models.Task{TaskType: "boolean"}, true, false},
+		{"boolean as a string", models.Task{TaskType: "boolean"}, "true", true},
+
+		{"number", models.Task{TaskType: "number"}, 2.5, false},
+		{"number as a string", models.Task{TaskType: "number"}, "2.5", true},
+		{"number NaN", models.Task{TaskType: "number"}, math.NaN(), true},
+		{"number infinite", models.Task{TaskType: "number"}, math.Inf(1), true},
+		{"number below min", models.Task{TaskType: "number", Config: models.TaskConfig{Min: ptr(1)}}, 0.5, true},
+		{"number above max", models.Task{TaskType: "number", Config: models.TaskConfig{Max: ptr(10)}}, 10.5, true},
+		{"number on the bounds", models.Task{TaskType: "number", Config: models.TaskConfig{Min: ptr(1), Max: ptr(10)}}, 10.0, false},
+
+		{"duration", models.Task{TaskType: "duration", Config: models.TaskConfig{Unit: "minutes"}}, 45.0, false},
+		{"negative duration", models.Task{TaskType: "duration"}, -1.0, true},
+
+		{"rating on the default scale", models.Task{TaskType: "rating"}, 7.0, false},
+		{"rating above the default scale", models.Task{TaskType: "rating"}, 11.0, true},
+		{"fractional rating", models.Task{TaskType: "rating"}, 7.5, true},
+		{"rating on a custom scale", models.Task{TaskType: "rating", Config: models.TaskConfig{Min: ptr(0), Max: ptr(5)}}, 0.0, false},
+
+		{"counter", models.Task{TaskType: "counter"}, 3.0, false},
+		{"negative counter", models.Task{TaskType: "counter"}, -1.0, true},
+		{"fractional counter", models.Task{TaskType: "counter"}, 1.5, true},
+
+		{"text", models.Task{TaskType: "text"}, "Felt good", false},
+		{"text not a string", models.Task{TaskType: "text"}, 1.0, true},
+		{"text at max_length", models.Task{TaskType: "text", Config: models.TaskConfig{MaxLength: 3}}, "äöü", false},
+		{"text over max_length", models.Task{TaskType: "text", Config: models.TaskConfig{MaxLength: 3}}, "abcd", true},
+		{"text over the default length", models.Task{TaskType: "text"}, strings.Repeat("a", DefaultMaxTextLength+1), true},
+
+		{"select option", models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{"easy", "hard"}}}, "hard", false},
+		{"select unknown option", models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{"easy", "hard"}}}, "medium", true},
+		{"select without options", models.Task{TaskType: "select"}, "anything", false},
+		{"select without options empty", models.Task{TaskType: "select"}, "", true},
+		{"select without options not a string", models.Task{TaskType: "select"}, 1.0, true},
+
+		{"multi_number", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}},
+			map[string]any{"am": 500.0, "pm": 750.0}, false},
+		{"multi_number with a field left out", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}},
+			map[string]any{"am": 500.0}, false},
+		{"multi_number with a null field", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}},
+			map[string]any{"am": 500.0, "pm": nil}, false},
+		{"multi_number with an unknown field", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}},
+			map[string]any{"am": 500.0, "noon": 250.0}, true},
+		{"multi_number with a string field", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}},
+			map[string]any{"am": "500"}, true},
+		{"multi_number field over max", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am"}, Max: ptr(1000)}},
+			map[string]any{"am": 1500.0}, true},
+		{"multi_number not an object", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am"}}},
+			500.0, true},
+
+		{"photo attachment ID", models.Task{TaskType: "photo"}, "8f14e45f-ceea-467f-a0e6-2d8a4b6c4a1e", false},
+		{"photo URL", models.Task{TaskType: "photo"}, "https://example.com/p.jpg", true},
+		{"photo not a string", models.Task{TaskType: "photo"}, 42.0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateValue(&tt.task, tt.value); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateValue = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    models.Task
+		wantErr bool
+	}{
+		{"unknown type", models.Task{TaskType: "colour"}, true},
+		{"number min over max", models.Task{TaskType: "number", Config: models.TaskConfig{Min: ptr(5), Max: ptr(1)}}, true},
+		{"duration unit", models.Task{TaskType: "duration", Config: models.TaskConfig{Unit: "days"}}, true},
+		{"rating scale reversed", models.Task{TaskType: "rating", Config: models.TaskConfig{Min: ptr(5), Max: ptr(5)}}, true},
+		{"rating scale fractional", models.Task{TaskType: "rating", Config: models.TaskConfig{Max: ptr(4.5)}}, true},
+		{"counter negative min", models.Task{TaskType: "counter", Config: models.TaskConfig{Min: ptr(-1)}}, true},
+		{"text negative max_length", models.Task{TaskType: "text", Config: models.TaskConfig{MaxLength: -1}}, true},
+		{"select without options", models.Task{TaskType: "select"}, false},
+		{"select duplicate option", models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{"a", "a"}}}, true},
+		{"select empty option", models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{""}}}, true},
+		{"multi_number without labels", models.Task{TaskType: "multi_number"}, true},
+		{"multi_number duplicate label", models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "am"}}}, true},
+
+		{"numeric target", models.Task{TaskType: "number", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "gte", Value: 10.0}}}, false},
+		{"numeric target unknown comparator", models.Task{TaskType: "number", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "between", Value: 10.0}}}, true},
+		{"numeric target not a number", models.Task{TaskType: "counter", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "gte", Value: "10"}}}, true},
+		{"boolean target", models.Task{TaskType: "boolean", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "eq", Value: true}}}, false},
+		{"boolean target with gte", models.Task{TaskType: "boolean", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "gte", Value: true}}}, true},
+		{"select target not an option", models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{"a"},
+			Target: &models.TaskTarget{Comparator: "eq", Value: "b"}}}, true},
+		{"text target", models.Task{TaskType: "text", Config: models.TaskConfig{
+			Target: &models.TaskTarget{Comparator: "eq", Value: "done"}}}, true},
+		{"default out of bounds", models.Task{TaskType: "number", Config: models.TaskConfig{Max: ptr(5), Default: 6.0}}, true},
+		{"default in bounds", models.Task{TaskType: "number", Config: models.TaskConfig{Max: ptr(5), Default: 5.0}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateConfig(&tt.task); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeetsTarget(t *testing.T) {
+	target := func(taskType, comparator string, value any) models.Task {
+		return models.Task{TaskType: taskType, Config: models.TaskConfig{
+			Labels: []string{"am", "pm"},
+			Target: &models.TaskTarget{Comparator: comparator, Value: value},
+		}}
+	}
+
+	tests := []struct {
+		name   string
+		task   models.Task
+		value  any
+		wantOK bool
+		want   bool
+	}{
+		{"no target", models.Task{TaskType: "number"}, 5.0, false, false},
+		{"nothing recorded", target("number", "gte", 5.0), nil, false, false},
+
+		{"gte met", target("number", "gte", 5.0), 5.0, true, true},
+		{"gte missed", target("number", "gte", 5.0), 4.9, true, false},
+		{"gt on the target", target("number", "gt", 5.0), 5.0, true, false},
+		{"lte met", target("duration", "lte", 30.0), 30.0, true, true},
+		{"lt on the target", target("duration", "lt", 30.0), 30.0, true, false},
+		{"eq met", target("counter", "eq", 3.0), 3.0, true, true},
+		{"eq missed", target("rating", "eq", 8.0), 7.0, true, false},
+		{"numeric target with a string value", target("number", "gte", 5.0), "5", false, false},
+
+		{"multi_number sum met", target("multi_number", "gte", 2000.0), map[string]any{"am": 1250.0, "pm": 750.0}, true, true},
+		{"multi_number sum missed", target("multi_number", "gte", 2000.0), map[string]any{"am": 1250.0, "pm": 700.0}, true, false},
+		{"multi_number with a field left out", target("multi_number", "gte", 1000.0), map[string]any{"am": 1250.0}, true, true},
+		{"multi_number with a null field", target("multi_number", "gte", 1000.0), map[string]any{"am": 900.0, "pm": nil}, true, false},
+		{"multi_number with no fields", target("multi_number", "lte", 0.0), map[string]any{}, true, true},
+
+		{"boolean met", target("boolean", "eq", true), true, true, true},
+		{"boolean missed", target("boolean", "eq", true), false, true, false},
+		{"select met", target("select", "eq", "hard"), "hard", true, true},
+		{"select missed", target("select", "eq", "hard"), "easy", true, false},
+
+		{"text has no targets", target("text", "eq", "done"), "done", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			met, ok := MeetsTarget(&tt.task, tt.value)
+			if ok != tt.wantOK || met != tt.want {
+				t.Errorf("MeetsTarget = %v, %v, want %v, %v", met, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Run("numbers", func(t *testing.T) {
+		task := &models.Task{TaskType: "number", Config: models.TaskConfig{Unit: "km"}}
+		stats := Summarize(task, []models.TaskEntry{
+			{Completed: true, Value: 5.0},
+			{Completed: true, Value: 10.0},
+			{Completed: false, Value: 3.0},
+			{Completed: false},
+		})
+
+		if stats.Entries != 4 || stats.Completed != 2 || stats.Unit != "km" {
+			t.Errorf("entries = %d, completed = %d, unit = %q, want 4, 2, km", stats.Entries, stats.Completed, stats.Unit)
+		}
+		want := NumberStats{Count: 3, Total: 18, Average: 6, Min: 3, Max: 10}
+		if stats.Numbers == nil || *stats.Numbers != want {
+			t.Errorf("numbers = %+v, want %+v", stats.Numbers, want)
+		}
+	})
+
+	t.Run("multi_number", func(t *testing.T) {
+		task := &models.Task{TaskType: "multi_number", Config: models.TaskConfig{Labels: []string{"am", "pm"}}}
+		stats := Summarize(task, []models.TaskEntry{
+			{Value: map[string]any{"am": 500.0, "pm": 700.0}},
+			{Value: map[string]any{"am": 300.0}},
+			{Value: map[string]any{"am": 400.0, "pm": nil}},
+			// No longer valid, so only counted as an entry
+			{Value: map[string]any{"noon": 100.0}},
+		})
+
+		if stats.Entries != 4 || stats.Numbers != nil {
+			t.Errorf("entries = %d, numbers = %+v, want 4, nil", stats.Entries, stats.Numbers)
+		}
+		wantAM := NumberStats{Count: 3, Total: 1200, Average: 400, Min: 300, Max: 500}
+		wantPM := NumberStats{Count: 1, Total: 700, Average: 700, Min: 700, Max: 700}
+		if am := stats.Fields["am"]; am == nil || *am != wantAM {
+			t.Errorf("am = %+v, want %+v", am, wantAM)
+		}
+		if pm := stats.Fields["pm"]; pm == nil || *pm != wantPM {
+			t.Errorf("pm = %+v, want %+v", pm, wantPM)
+		}
+		if _, ok := stats.Fields["noon"]; ok {
+			t.Error("noon was summarised, want it skipped")
+		}
+	})
+
+	t.Run("select without options", func(t *testing.T) {
+		task := &models.Task{TaskType: "select"}
+		stats := Summarize(task, []models.TaskEntry{
+			{Value: "run"},
+			{Value: "swim"},
+			{Value: "run"},
+			{Value: ""},
+		})
+
+		if len(stats.Options) != 2 || stats.Options["run"] != 2 || stats.Options["swim"] != 1 {
+			t.Errorf("options = %v, want run: 2, swim: 1", stats.Options)
+		}
+	})
+
+	t.Run("entries read against their revision", func(t *testing.T) {
+		// The task is a select now, but used to be a counter
+		task := &models.Task{TaskType: "select", Config: models.TaskConfig{Options: []string{"a", "b"}}}
+		old := &models.Task{TaskType: "counter"}
+		stats := Summarize(task, []models.TaskEntry{
+			{Value: 4.0, Task: old},
+			{Value: 6.0, Task: old},
+			{Value: "a"},
+			// A counter value read against the current revision doesn't fit
+			{Value: 2.0},
+		})
+
+		want := NumberStats{Count: 2, Total: 10, Average: 5, Min: 4, Max: 6}
+		if stats.Numbers == nil || *stats.Numbers != want {
+			t.Errorf("numbers = %+v, want %+v", stats.Numbers, want)
+		}
+		if stats.Entries != 4 || stats.Options["a"] != 1 || len(stats.Options) != 1 {
+			t.Errorf("entries = %d, options = %v, want 4, a: 1", stats.Entries, stats.Options)
+		}
+	})
+
+	t.Run("booleans and photos", func(t *testing.T) {
+		booleans := Summarize(&models.Task{TaskType: "boolean"}, []models.TaskEntry{
+			{Value: true}, {Value: false}, {Value: true}, {Value: "yes"},
+		})
+		if booleans.Checked != 2 {
+			t.Errorf("checked = %d, want 2", booleans.Checked)
+		}
+
+		photos := Summarize(&models.Task{TaskType: "photo"}, []models.TaskEntry{
+			{Value: "8f14e45f-ceea-467f-a0e6-2d8a4b6c4a1e"}, {Value: "https://example.com/1.jpg"},
+		})
+		if photos.Photos != 1 {
+			t.Errorf("photos = %d, want 1", photos.Photos)
+		}
+	})
+}
+
+func TestAttachmentID(t *testing.T) {
+	const id = "8f14e45f-ceea-467f-a0e6-2d8a4b6c4a1e"
+
+	tests := []struct {
+		name   string
+		task   models.Task
+		value  any
+		wantOK bool
+	}{
+		{"photo", models.Task{TaskType: "photo"}, id, true},
+		{"photo URL", models.Task{TaskType: "photo"}, "https://example.com/p.jpg", false},
+		{"photo without a value", models.Task{TaskType: "photo"}, nil, false},
+		{"text holding an ID", models.Task{TaskType: "text"}, id, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := AttachmentID(&tt.task, tt.value)
+			if ok != tt.wantOK || (ok && got.String() != id) {
+				t.Errorf("AttachmentID = %s, %v, want %v", got, ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package tasktypes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+// Ratings without configured bounds use a 1-10 scale
+const (
+	defaultRatingMin = 1
+	defaultRatingMax = 10
+)
+
+var durationUnits = map[string]bool{"": true, "seconds": true, "minutes": true, "hours": true}
+
+type booleanType struct{}
+
+func (booleanType) ValidateConfig(models.TaskConfig) error { return nil }
+
+func (booleanType) ValidateValue(_ models.TaskConfig, value any) error {
+	if _, ok := value.(bool); !ok {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func (booleanType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	if value.(bool) {
+		stats.Checked++
+	}
+}
+
+type numberType struct{}
+
+func (numberType) ValidateConfig(cfg models.TaskConfig) error {
+	return checkBoundsConfig(cfg)
+}
+
+func (numberType) ValidateValue(cfg models.TaskConfig, value any) error {
+	n, err := number(value)
+	if err != nil {
+		return err
+	}
+	return checkBounds(cfg, n)
+}
+
+func (numberType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	stats.addNumber(value.(float64))
+}
+
+// durationType records how long something took, in the configured unit
+type durationType struct{}
+
+func (durationType) ValidateConfig(cfg models.TaskConfig) error {
+	if !durationUnits[cfg.Unit] {
+		return errors.New("unit must be seconds, minutes or hours")
+	}
+	return checkBoundsConfig(cfg)
+}
+
+func (durationType) ValidateValue(cfg models.TaskConfig, value any) error {
+	n, err := number(value)
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return errors.New("must not be negative")
+	}
+	return checkBounds(cfg, n)
+}
+
+func (durationType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	stats.addNumber(value.(float64))
+}
+
+// ratingType records a whole number on a scale from Min to Max
+type ratingType struct{}
+
+func (ratingType) ValidateConfig(cfg models.TaskConfig) error {
+	lo, hi := ratingScale(cfg)
+	if lo != math.Trunc(lo) || hi != math.Trunc(hi) {
+		return errors.New("min and max must be whole numbers")
+	}
+	if lo >= hi {
+		return errors.New("min must be less than max")
+	}
+	return nil
+}
+
+func (ratingType) ValidateValue(cfg models.TaskConfig, value any) error {
+	n, err := number(value)
+	if err != nil {
+		return err
+	}
+	lo, hi := ratingScale(cfg)
+	if n != math.Trunc(n) || n < lo || n > hi {
+		return fmt.Errorf("must be a whole number from %g to %g", lo, hi)
+	}
+	return nil
+}
+
+func (ratingType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	stats.addNumber(value.(float64))
+}
+
+func ratingScale(cfg models.TaskConfig) (float64, float64) {
+	lo, hi := float64(defaultRatingMin), float64(defaultRatingMax)
+	if cfg.Min != nil {
+		lo = *cfg.Min
+	}
+	if cfg.Max != nil {
+		hi = *cfg.Max
+	}
+	return lo, hi
+}
+
+// counterType records how many times something happened
+type counterType struct{}
+
+func (counterType) ValidateConfig(cfg models.TaskConfig) error {
+	if cfg.Min != nil && *cfg.Min < 0 {
+		return errors.New("min must not be negative")
+	}
+	return checkBoundsConfig(cfg)
+}
+
+func (counterType) ValidateValue(cfg models.TaskConfig, value any) error {
+	n, err := number(value)
+	if err != nil {
+		return err
+	}
+	if n < 0 || n != math.Trunc(n) {
+		return errors.New("must be a whole number of at least 0")
+	}
+	return checkBounds(cfg, n)
+}
+
+func (counterType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	stats.addNumber(value.(float64))
+}
+
+// multiNumberType records one number per labelled sub-field, e.g. water
+// intake in the morning, afternoon and evening. Values are objects keyed by
+// label; sub-fields may be left out.
+type multiNumberType struct{}
+
+func (multiNumberType) ValidateConfig(cfg models.TaskConfig) error {
+	if len(cfg.Labels) == 0 {
+		return errors.New("labels must name at least one field")
+	}
+	seen := make(map[string]bool, len(cfg.Labels))
+	for _, label := range cfg.Labels {
+		if label == "" {
+			return errors.New("labels must not be empty")
+		}
+		if seen[label] {
+			return fmt.Errorf("label %q is listed twice", label)
+		}
+		seen[label] = true
+	}
+	return checkBoundsConfig(cfg)
+}
+
+func (multiNumberType) ValidateValue(cfg models.TaskConfig, value any) error {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("must be an object with the fields %q", cfg.Labels)
+	}
+
+	for _, label := range cfg.Labels {
+		v, ok := fields[label]
+		if !ok || v == nil {
+			continue
+		}
+		n, err := number(v)
+		if err == nil {
+			err = checkBounds(cfg, n)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %w", label, err)
+		}
+	}
+
+	if len(fields) > len(cfg.Labels) {
+		return fmt.Errorf("must only have the fields %q", cfg.Labels)
+	}
+	for label := range fields {
+		if !contains(cfg.Labels, label) {
+			return fmt.Errorf("must only have the fields %q", cfg.Labels)
+		}
+	}
+	return nil
+}
+
+func (multiNumberType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	for label, v := range value.(map[string]any) {
+		if n, ok := v.(float64); ok {
+			stats.addField(label, n)
+		}
+	}
+}
+
+type textType struct{}
+
+func (textType) ValidateConfig(cfg models.TaskConfig) error {
+	if cfg.MaxLength < 0 {
+		return errors.New("max_length must not be negative")
+	}
+	return nil
+}
+
+func (textType) ValidateValue(cfg models.TaskConfig, value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+
+	limit := cfg.MaxLength
+	if limit == 0 {
+		limit = DefaultMaxTextLength
+	}
+	if utf8.RuneCountInString(s) > limit {
+		return fmt.Errorf("must be at most %d characters", limit)
+	}
+	return nil
+}
+
+func (textType) Summarize(models.TaskConfig, any, *Stats) {}
+
+type selectType struct{}
+
+func (selectType) ValidateConfig(cfg models.TaskConfig) error {
+	seen := make(map[string]bool, len(cfg.Options))
+	for _, option := range cfg.Options {
+		if option == "" {
+			return errors.New("options must not be empty")
+		}
+		if seen[option] {
+			return fmt.Errorf("option %q is listed twice", option)
+		}
+		seen[option] = true
+	}
+	return nil
+}
+
+// ValidateValue accepts any non-empty string for a select without options,
+// which older tasks were created as
+func (selectType) ValidateValue(cfg models.TaskConfig, value any) error {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return errors.New("must be one of the task's options")
+	}
+	if len(cfg.Options) == 0 || contains(cfg.Options, s) {
+		return nil
+	}
+	return fmt.Errorf("must be one of %q", cfg.Options)
+}
+
+func (selectType) Summarize(_ models.TaskConfig, value any, stats *Stats) {
+	if stats.Options == nil {
+		stats.Options = map[string]int{}
+	}
+	stats.Options[value.(string)]++
+}
+
+// photoType records a photo uploaded as an attachment, by its ID. Whether the
+// attachment belongs to the entry's owner is up to the caller; see
+// AttachmentID.
+type photoType struct{}
+
+func (photoType) ValidateConfig(models.TaskConfig) error { return nil }
+
+func (photoType) ValidateValue(_ models.TaskConfig, value any) error {
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("must be the ID of an uploaded attachment")
+	}
+	if _, err := uuid.Parse(s); err != nil {
+		return errors.New("must be the ID of an uploaded attachment")
+	}
+	return nil
+}
+
+func (photoType) Summarize(_ models.TaskConfig, _ any, stats *Stats) {
+	stats.Photos++
+}
+
+// number returns value as a finite number
+func number(value any) (float64, error) {
+	n, ok := value.(float64)
+	if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, errors.New("must be a number")
+	}
+	return n, nil
+}
+
+func checkBoundsConfig(cfg models.TaskConfig) error {
+	if cfg.Min != nil && cfg.Max != nil && *cfg.Min > *cfg.Max {
+		return errors.New("min must not be greater than max")
+	}
+	return nil
+}
+
+// checkBounds applies the optional min and max of cfg to n
+func checkBounds(cfg models.TaskConfig, n float64) error {
+	if cfg.Min != nil && n < *cfg.Min {
+		return fmt.Errorf("must be at least %g", *cfg.Min)
+	}
+	if cfg.Max != nil && n > *cfg.Max {
+		return fmt.Errorf("must be at most %g", *cfg.Max)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
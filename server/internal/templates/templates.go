@@ -0,0 +1,67 @@
+// Package templates provides the built-in challenge templates that ship with
+// the API, loaded from the embedded JSON files in builtin/.
+package templates
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/hari4698/hardinfinity/internal/models"
+)
+
+//go:embed builtin/*.json
+var builtinFS embed.FS
+
+// builtin is parsed once at start-up; a malformed file is a programming error
+var builtin = mustLoad(builtinFS)
+
+func mustLoad(fsys fs.FS) []models.Template {
+	files, err := fs.Glob(fsys, "builtin/*.json")
+	if err != nil {
+		panic(err)
+	}
+
+	templates := make([]models.Template, 0, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			panic(err)
+		}
+
+		var t models.Template
+		if err := json.Unmarshal(data, &t); err != nil {
+			panic(fmt.Sprintf("templates: parse %s: %v", file, err))
+		}
+		if t.ID == uuid.Nil || t.Name == "" {
+			panic(fmt.Sprintf("templates: %s needs an id and a name", file))
+		}
+
+		t.Builtin = true
+		templates = append(templates, t)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// Builtin returns a copy of every built-in template
+func Builtin() []models.Template {
+	templates := make([]models.Template, len(builtin))
+	copy(templates, builtin)
+	return templates
+}
+
+// FindBuiltin returns the built-in template with the given ID
+func FindBuiltin(id uuid.UUID) (*models.Template, bool) {
+	for _, t := range builtin {
+		if t.ID == id {
+			t := t
+			return &t, true
+		}
+	}
+	return nil, false
+}
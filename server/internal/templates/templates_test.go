@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/hari4698/hardinfinity/internal/models"
+	"github.com/hari4698/hardinfinity/internal/schedule"
+	"github.com/hari4698/hardinfinity/internal/tasktypes"
+)
+
+// TestBuiltin checks that every built-in template can be instantiated: the
+// handlers create its tasks without validating them again
+func TestBuiltin(t *testing.T) {
+	templates := Builtin()
+	if len(templates) == 0 {
+		t.Fatal("no built-in templates")
+	}
+
+	seen := map[string]bool{}
+	for _, tmpl := range templates {
+		if seen[tmpl.ID.String()] {
+			t.Errorf("template ID %s is used twice", tmpl.ID)
+		}
+		seen[tmpl.ID.String()] = true
+
+		if !tmpl.Builtin || tmpl.UserID != nil {
+			t.Errorf("%s is not marked built-in", tmpl.Name)
+		}
+		if tmpl.DurationDays < 1 || len(tmpl.Sections) == 0 {
+			t.Errorf("%s lasts %d days with %d sections, want a length and sections", tmpl.Name, tmpl.DurationDays, len(tmpl.Sections))
+		}
+
+		for _, section := range tmpl.Sections {
+			for _, tt := range section.Tasks {
+				task := models.Task{
+					Name: tt.Name, TaskType: tt.TaskType, Required: tt.Required, RestartOnFail: tt.RestartOnFail,
+					StrikesEnabled: tt.StrikesEnabled, StrikesLimit: tt.StrikesLimit, Config: tt.Config, Schedule: tt.Schedule,
+				}
+				if err := tasktypes.ValidateConfig(&task); err != nil {
+					t.Errorf("%s: %s: %v", tmpl.Name, tt.Name, err)
+				}
+				if task.Schedule.Kind != "" {
+					if err := schedule.Validate(task.Schedule); err != nil {
+						t.Errorf("%s: %s: %v", tmpl.Name, tt.Name, err)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestFindBuiltin(t *testing.T) {
+	want := Builtin()[0]
+
+	got, ok := FindBuiltin(want.ID)
+	if !ok || got.Name != want.Name {
+		t.Fatalf("FindBuiltin(%s) = %v, %v, want %s", want.ID, got, ok, want.Name)
+	}
+
+	// The result is a copy
+	got.Name = "changed"
+	if again, _ := FindBuiltin(want.ID); again.Name != want.Name {
+		t.Error("changing a found template changed the built-in")
+	}
+}
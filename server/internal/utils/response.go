@@ -9,6 +9,13 @@ type Response struct {
 	Success bool `json:"success"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   string      `json:"error,omitempty"`	
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // JSON sends a JSON response with appropriate headers
@@ -34,4 +41,14 @@ func Error(w http.ResponseWriter, statusCode int, err string) {
 		Error:   err,
 	}
 	JSON(w, statusCode, response)
+}
+
+// ValidationError sends a 422 response listing every rejected field
+func ValidationError(w http.ResponseWriter, err string, fields []FieldError) {
+	response := Response{
+		Success: false,
+		Error:   err,
+		Errors:  fields,
+	}
+	JSON(w, http.StatusUnprocessableEntity, response)
 }
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS measurements;
+DROP TABLE IF EXISTS task_entries;
+DROP TABLE IF EXISTS daily_entries;
+DROP TABLE IF EXISTS tasks;
+DROP TABLE IF EXISTS sections;
+DROP TABLE IF EXISTS challenges;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1 @@
+ALTER TABLE challenges DROP COLUMN IF EXISTS duration_days;
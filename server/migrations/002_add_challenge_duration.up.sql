@@ -0,0 +1,11 @@
+ALTER TABLE challenges
+    ADD COLUMN duration_days INTEGER NOT NULL DEFAULT 75 CHECK (duration_days > 0);
+
+-- Challenges that already had an end date keep the length it implies
+UPDATE challenges
+SET duration_days = end_date - start_date + 1
+WHERE end_date IS NOT NULL AND end_date >= start_date;
+
+UPDATE challenges
+SET end_date = start_date + duration_days - 1
+WHERE end_date IS NULL;
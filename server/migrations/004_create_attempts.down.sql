@@ -0,0 +1,11 @@
+-- Only the latest attempt's entries fit the old one-entry-per-day constraint
+DELETE FROM daily_entries d
+USING attempts a
+WHERE a.id = d.attempt_id
+  AND a.attempt_number < (SELECT MAX(attempt_number) FROM attempts WHERE challenge_id = a.challenge_id);
+
+ALTER TABLE daily_entries DROP CONSTRAINT daily_entries_attempt_id_day_number_key;
+ALTER TABLE daily_entries ADD CONSTRAINT daily_entries_challenge_id_day_number_key UNIQUE (challenge_id, day_number);
+ALTER TABLE daily_entries DROP COLUMN attempt_id;
+
+DROP TABLE IF EXISTS attempts;
@@ -0,0 +1,36 @@
+CREATE TABLE attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    attempt_number INTEGER NOT NULL,
+    start_date DATE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMP WITH TIME ZONE,
+    end_reason TEXT,
+    day_reached INTEGER,
+    UNIQUE (challenge_id, attempt_number)
+);
+
+-- At most one attempt per challenge is still running
+CREATE UNIQUE INDEX idx_attempts_open ON attempts(challenge_id) WHERE ended_at IS NULL;
+
+-- Every existing challenge gets a first attempt, closed if it already finished
+INSERT INTO attempts (challenge_id, attempt_number, start_date, started_at, ended_at, end_reason, day_reached)
+SELECT id, 1, start_date, created_at,
+       CASE WHEN status = 'active' THEN NULL ELSE updated_at END,
+       CASE WHEN status = 'active' THEN NULL ELSE status END,
+       CASE WHEN status = 'active' THEN NULL ELSE current_day END
+FROM challenges;
+
+ALTER TABLE daily_entries ADD COLUMN attempt_id UUID REFERENCES attempts(id) ON DELETE CASCADE;
+
+UPDATE daily_entries d
+SET attempt_id = a.id
+FROM attempts a
+WHERE a.challenge_id = d.challenge_id AND a.attempt_number = 1;
+
+ALTER TABLE daily_entries ALTER COLUMN attempt_id SET NOT NULL;
+ALTER TABLE daily_entries DROP CONSTRAINT daily_entries_challenge_id_day_number_key;
+ALTER TABLE daily_entries ADD CONSTRAINT daily_entries_attempt_id_day_number_key UNIQUE (attempt_id, day_number);
+
+CREATE INDEX idx_attempts_challenge_id ON attempts(challenge_id);
+CREATE INDEX idx_daily_entries_attempt_id ON daily_entries(attempt_id);
@@ -0,0 +1,2 @@
+ALTER TABLE challenges DROP COLUMN timezone;
+ALTER TABLE users DROP COLUMN timezone;
@@ -0,0 +1,3 @@
+-- IANA timezone names. A challenge without its own timezone follows its user.
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE challenges ADD COLUMN timezone TEXT;
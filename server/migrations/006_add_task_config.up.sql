@@ -0,0 +1,2 @@
+-- Type-specific task settings such as number bounds or select options
+ALTER TABLE tasks ADD COLUMN config JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,7 @@
+-- Fold the newer types into the closest original one. Recorded values are
+-- left as they are.
+UPDATE tasks SET task_type = 'number' WHERE task_type IN ('duration', 'rating', 'counter');
+UPDATE tasks SET task_type = 'text' WHERE task_type IN ('multi_number', 'photo');
+
+ALTER TABLE tasks DROP CONSTRAINT tasks_task_type_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_task_type_check CHECK (task_type IN ('boolean', 'number', 'text', 'select'));
@@ -0,0 +1,5 @@
+ALTER TABLE tasks DROP CONSTRAINT tasks_task_type_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_task_type_check CHECK (task_type IN (
+    'boolean', 'number', 'text', 'select',
+    'duration', 'rating', 'counter', 'multi_number', 'photo'
+));
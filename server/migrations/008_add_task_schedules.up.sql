@@ -0,0 +1,2 @@
+-- When a task is due; see models.TaskSchedule
+ALTER TABLE tasks ADD COLUMN schedule JSONB NOT NULL DEFAULT '{"kind": "daily"}';
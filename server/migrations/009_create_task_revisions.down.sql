@@ -0,0 +1,9 @@
+ALTER TABLE task_entries DROP COLUMN task_revision_id;
+
+DROP TABLE IF EXISTS task_revisions;
+
+-- Archived tasks would reappear otherwise
+DELETE FROM tasks WHERE archived_at IS NOT NULL;
+
+ALTER TABLE tasks DROP COLUMN archived_at;
+ALTER TABLE tasks DROP COLUMN revision;
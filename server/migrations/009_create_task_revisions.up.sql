@@ -0,0 +1,41 @@
+-- Tasks are archived instead of deleted so their entries survive, and every
+-- edit bumps the revision number
+ALTER TABLE tasks ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tasks ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+-- A snapshot of each revision of a task, as it was defined at the time
+CREATE TABLE task_revisions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    task_type TEXT NOT NULL,
+    required BOOLEAN,
+    restart_on_fail BOOLEAN,
+    strikes_enabled BOOLEAN,
+    strikes_limit INTEGER,
+    config JSONB NOT NULL,
+    schedule JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (task_id, revision)
+);
+
+INSERT INTO task_revisions (task_id, revision, name, description, task_type, required, restart_on_fail,
+                            strikes_enabled, strikes_limit, config, schedule, created_at)
+SELECT id, revision, name, description, task_type, required, restart_on_fail,
+       strikes_enabled, strikes_limit, config, schedule, updated_at
+FROM tasks;
+
+-- Task entries point at the revision they were recorded against
+ALTER TABLE task_entries ADD COLUMN task_revision_id UUID REFERENCES task_revisions(id);
+
+UPDATE task_entries te
+SET task_revision_id = r.id
+FROM task_revisions r
+WHERE r.task_id = te.task_id;
+
+ALTER TABLE task_entries ALTER COLUMN task_revision_id SET NOT NULL;
+
+CREATE INDEX idx_task_revisions_task_id ON task_revisions(task_id);
+CREATE INDEX idx_task_entries_task_revision_id ON task_entries(task_revision_id);
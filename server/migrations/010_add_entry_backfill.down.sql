@@ -0,0 +1,4 @@
+ALTER TABLE daily_entries DROP COLUMN edited_at;
+ALTER TABLE daily_entries DROP COLUMN backfilled;
+
+ALTER TABLE challenges DROP COLUMN grace_hours;
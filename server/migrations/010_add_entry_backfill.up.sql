@@ -0,0 +1,7 @@
+-- Hours after a day ends during which it can still be logged or edited;
+-- NULL uses the default window
+ALTER TABLE challenges ADD COLUMN grace_hours INTEGER CHECK (grace_hours BETWEEN 0 AND 168);
+
+-- Entries logged or changed after their day ended are flagged
+ALTER TABLE daily_entries ADD COLUMN backfilled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE daily_entries ADD COLUMN edited_at TIMESTAMP WITH TIME ZONE;
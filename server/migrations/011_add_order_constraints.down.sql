@@ -0,0 +1,2 @@
+ALTER TABLE tasks DROP CONSTRAINT tasks_section_id_order_index_excl;
+ALTER TABLE sections DROP CONSTRAINT sections_challenge_id_order_index_key;
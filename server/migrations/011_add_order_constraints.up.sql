@@ -0,0 +1,28 @@
+-- Renumber from 1 so no two sections of a challenge, or live tasks of a
+-- section, share an order before the constraints go in
+UPDATE sections s
+SET order_index = ranked.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY challenge_id ORDER BY order_index, created_at, id) AS position
+    FROM sections
+) ranked
+WHERE ranked.id = s.id;
+
+UPDATE tasks t
+SET order_index = ranked.position
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY section_id ORDER BY order_index, created_at, id) AS position
+    FROM tasks
+    WHERE archived_at IS NULL
+) ranked
+WHERE ranked.id = t.id;
+
+-- Deferrable so a reorder can pass through duplicate orders within its
+-- transaction
+ALTER TABLE sections ADD CONSTRAINT sections_challenge_id_order_index_key
+    UNIQUE (challenge_id, order_index) DEFERRABLE INITIALLY IMMEDIATE;
+
+-- Archived tasks keep the order they had and are left out
+ALTER TABLE tasks ADD CONSTRAINT tasks_section_id_order_index_excl
+    EXCLUDE USING btree (section_id WITH =, order_index WITH =) WHERE (archived_at IS NULL)
+    DEFERRABLE INITIALLY IMMEDIATE;
@@ -0,0 +1,2 @@
+ALTER TABLE daily_entries DROP COLUMN progress_thumbnail_key;
+ALTER TABLE daily_entries DROP COLUMN progress_photo_key;
@@ -0,0 +1,4 @@
+-- Blob keys of an uploaded progress photo and its thumbnail. The photos are
+-- served through signed URLs, so only the keys are stored.
+ALTER TABLE daily_entries ADD COLUMN progress_photo_key TEXT;
+ALTER TABLE daily_entries ADD COLUMN progress_thumbnail_key TEXT;
@@ -0,0 +1,15 @@
+ALTER TABLE daily_entries ADD COLUMN progress_photo_key TEXT;
+ALTER TABLE daily_entries ADD COLUMN progress_thumbnail_key TEXT;
+
+-- Only the first progress photo of each entry can be kept
+UPDATE daily_entries d
+SET progress_photo_key = a.blob_key, progress_thumbnail_key = a.thumbnail_key
+FROM (
+    SELECT DISTINCT ON (daily_entry_id) daily_entry_id, blob_key, thumbnail_key
+    FROM attachments
+    WHERE kind = 'progress_photo' AND daily_entry_id IS NOT NULL
+    ORDER BY daily_entry_id, order_index, created_at
+) a
+WHERE a.daily_entry_id = d.id;
+
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,32 @@
+-- Files attached to a daily entry or to one of its task entries, such as
+-- progress photos or workout screenshots. Only blob keys are stored; the
+-- files are served through signed URLs.
+CREATE TABLE attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    daily_entry_id UUID REFERENCES daily_entries(id) ON DELETE CASCADE,
+    task_entry_id UUID REFERENCES task_entries(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('progress_photo', 'photo', 'screenshot')),
+    mime_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    blob_key TEXT NOT NULL,
+    thumbnail_key TEXT,
+    order_index INTEGER NOT NULL,
+    caption TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT attachments_parent_check CHECK (num_nonnulls(daily_entry_id, task_entry_id) = 1)
+);
+
+CREATE INDEX idx_attachments_daily_entry_id ON attachments(daily_entry_id);
+CREATE INDEX idx_attachments_task_entry_id ON attachments(task_entry_id);
+
+-- Photos uploaded so far become the first attachment of their entry. Their
+-- size was never recorded.
+INSERT INTO attachments (daily_entry_id, kind, mime_type, size_bytes, blob_key, thumbnail_key,
+                         order_index, created_at, updated_at)
+SELECT id, 'progress_photo', 'image/jpeg', 0, progress_photo_key, progress_thumbnail_key, 1, updated_at, updated_at
+FROM daily_entries
+WHERE progress_photo_key IS NOT NULL;
+
+ALTER TABLE daily_entries DROP COLUMN progress_thumbnail_key;
+ALTER TABLE daily_entries DROP COLUMN progress_photo_key;
@@ -0,0 +1,3 @@
+UPDATE measurements
+SET weight = COALESCE(weight, 0), chest = COALESCE(chest, 0), waist = COALESCE(waist, 0),
+    hips = COALESCE(hips, 0), arms = COALESCE(arms, 0), thighs = COALESCE(thighs, 0);
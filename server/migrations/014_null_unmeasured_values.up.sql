@@ -0,0 +1,4 @@
+-- Values that were never measured used to be stored as 0; they are NULL now
+UPDATE measurements
+SET weight = NULLIF(weight, 0), chest = NULLIF(chest, 0), waist = NULLIF(waist, 0),
+    hips = NULLIF(hips, 0), arms = NULLIF(arms, 0), thighs = NULLIF(thighs, 0);
@@ -0,0 +1,12 @@
+ALTER TABLE task_entries DROP CONSTRAINT task_entries_task_id_fkey;
+ALTER TABLE task_entries ADD CONSTRAINT task_entries_task_id_fkey
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE;
+
+-- Archived sections would reappear otherwise
+DELETE FROM sections WHERE archived_at IS NOT NULL;
+
+ALTER TABLE sections DROP CONSTRAINT sections_challenge_id_order_index_excl;
+ALTER TABLE sections ADD CONSTRAINT sections_challenge_id_order_index_key
+    UNIQUE (challenge_id, order_index) DEFERRABLE INITIALLY IMMEDIATE;
+
+ALTER TABLE sections DROP COLUMN archived_at;
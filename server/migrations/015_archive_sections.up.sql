@@ -0,0 +1,14 @@
+-- Sections are archived along with their tasks instead of deleted, so the
+-- entries and revisions of those tasks survive
+ALTER TABLE sections ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+-- Archived sections keep the order they had and are left out
+ALTER TABLE sections DROP CONSTRAINT sections_challenge_id_order_index_key;
+ALTER TABLE sections ADD CONSTRAINT sections_challenge_id_order_index_excl
+    EXCLUDE USING btree (challenge_id WITH =, order_index WITH =) WHERE (archived_at IS NULL)
+    DEFERRABLE INITIALLY IMMEDIATE;
+
+-- Deleting a task must not take its recorded entries with it. Entries still
+-- go when their daily entry does.
+ALTER TABLE task_entries DROP CONSTRAINT task_entries_task_id_fkey;
+ALTER TABLE task_entries ADD CONSTRAINT task_entries_task_id_fkey FOREIGN KEY (task_id) REFERENCES tasks(id);
@@ -0,0 +1 @@
+ALTER TABLE daily_entries ADD COLUMN progress_photo_url TEXT;
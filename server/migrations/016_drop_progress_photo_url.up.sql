@@ -0,0 +1,3 @@
+-- Progress photos are uploaded as attachments and served through signed
+-- URLs; links supplied by clients are no longer kept
+ALTER TABLE daily_entries DROP COLUMN progress_photo_url;
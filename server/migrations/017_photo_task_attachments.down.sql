@@ -0,0 +1 @@
+-- The photo links cleared by the up migration are gone for good
@@ -0,0 +1,10 @@
+-- Photo task values are the IDs of uploaded attachments; links supplied by
+-- clients are no longer kept
+UPDATE task_entries te
+SET value = NULL
+FROM task_revisions r
+WHERE r.id = te.task_revision_id
+  AND r.task_type = 'photo'
+  AND te.value IS NOT NULL
+  AND NOT (jsonb_typeof(te.value) = 'string'
+           AND te.value #>> '{}' ~* '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$');
@@ -0,0 +1 @@
+-- The dates the first revisions had before are not kept
@@ -0,0 +1,10 @@
+-- Past days are judged against the task revisions in effect on them. The
+-- revisions snapshotted when revisions were introduced are dated by the
+-- task's last edit, so the earliest revision of each task is dated back to
+-- when the task was created.
+UPDATE task_revisions r
+SET created_at = t.created_at
+FROM tasks t
+WHERE t.id = r.task_id
+  AND r.created_at > t.created_at
+  AND r.revision = (SELECT MIN(revision) FROM task_revisions WHERE task_id = r.task_id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations so they ship inside the
+// API binary.
+package migrations
+
+import "embed"
+
+// FS holds every NNN_name.up.sql and NNN_name.down.sql file in this directory
+//
+//go:embed *.sql
+var FS embed.FS